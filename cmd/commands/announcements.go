@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/harrybrwn/config"
+	"github.com/harrybrwn/edu/cmd/internal"
+	"github.com/harrybrwn/edu/cmd/internal/announce"
+	"github.com/harrybrwn/edu/cmd/internal/opts"
+	"github.com/harrybrwn/edu/pkg/term"
+	"github.com/harrybrwn/go-canvas"
+	"github.com/jaytaylor/html2text"
+	"github.com/spf13/cobra"
+)
+
+func newAnnouncementsCmd(globals *opts.Global) *cobra.Command {
+	var (
+		unread   bool
+		full     bool
+		markRead bool
+		nolinks  bool
+		days     = 14
+		basedir  = os.ExpandEnv(config.GetString("basedir"))
+	)
+	c := &cobra.Command{
+		Use:   "announcements [course]",
+		Short: "List course announcements.",
+		Long: `List the announcements from your current courses, newest first.
+
+Announcements are marked as read when they are shown with --full or
+when --mark-read is given. Use --unread to only list the announcements
+that have not been read.`,
+		Aliases: []string{"ann"},
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var courses []*canvas.Course
+			if len(args) > 0 {
+				course, err := internal.FindCourse(args[0])
+				if err != nil {
+					return internal.HandleAuthErr(err)
+				}
+				courses = append(courses, course)
+			} else {
+				var err error
+				if courses, err = internal.GetCourses(false); err != nil {
+					return internal.HandleAuthErr(err)
+				}
+			}
+			state, err := announce.OpenState(basedir)
+			if err != nil {
+				return err
+			}
+			since := time.Now().AddDate(0, 0, -days)
+			list := announce.Fetch(courses, since, internal.Errors)
+			if unread {
+				list = state.Unread(list)
+			}
+
+			if full {
+				for _, a := range list {
+					title, course := a.Title, a.Course
+					if !globals.NoColor {
+						title, course = term.Colorf("%m", title), term.Colorf("%b", course)
+					}
+					cmd.Printf("%s %s\n%s by %s, %s\n", title, course,
+						a.PostedAt.Local().Format(time.RFC822), a.UserName, a.HTMLURL)
+					text, err := html2text.FromString(a.Message, html2text.Options{
+						PrettyTables: true,
+						OmitLinks:    nolinks,
+					})
+					if err != nil {
+						return err
+					}
+					cmd.Printf("\n%s\n\n", text)
+				}
+			} else {
+				tab := internal.NewTable(cmd.OutOrStdout())
+				internal.SetTableHeader(tab, []string{"", "id", "posted", "course", "title"}, !globals.NoColor)
+				for _, a := range list {
+					mark := ""
+					if !state.IsRead(a) {
+						mark = "*"
+					}
+					tab.Append([]string{
+						mark,
+						strconv.Itoa(a.ID),
+						a.PostedAt.Local().Format(time.RFC822),
+						a.Course,
+						a.Title,
+					})
+				}
+				tab.Render()
+			}
+			if full || markRead {
+				state.MarkRead(list...)
+				if err = state.Save(); err != nil {
+					return err
+				}
+			}
+			return internal.Errors.Finish(cmd.ErrOrStderr())
+		},
+	}
+	flags := c.Flags()
+	flags.BoolVarP(&unread, "unread", "u", false, "only show announcements that have not been read")
+	flags.BoolVarP(&full, "full", "f", false, "show the full announcements and mark them as read")
+	flags.BoolVarP(&markRead, "mark-read", "m", false, "mark the listed announcements as read")
+	flags.BoolVar(&nolinks, "no-links", false, "hide links in the announcement text")
+	flags.IntVarP(&days, "days", "d", days, "show announcements from this many days back")
+	flags.StringVar(&basedir, "base-dir", basedir, "base directory where the read state is kept")
+	return c
+}
@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/harrybrwn/config"
+	"github.com/harrybrwn/edu/cmd/internal"
+	"github.com/harrybrwn/edu/cmd/internal/archive"
+	"github.com/spf13/cobra"
+)
+
+func newArchiveCmd() *cobra.Command {
+	arc := &archive.Archiver{
+		Jobs:   config.GetInt("jobs"),
+		Errors: internal.Errors,
+	}
+	var dir string
+	cmd := &cobra.Command{
+		Use:   "archive <course>",
+		Short: "Save a course as a static website",
+		Long: `Save a course as a static website that can be browsed offline.
+
+The archive has the syllabus, modules, pages, assignments with your
+submissions and grades, announcements, discussions, and files of the
+course. It is written to '<base-dir>/archive/<course name>' with an
+index.html and a course.json that has all the course data.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			course, err := internal.FindCourse(args[0])
+			if err != nil {
+				return internal.HandleAuthErr(err)
+			}
+			internal.Errors.Attempt(course.Name)
+			arc.Dir = dir
+			if arc.Dir == "" {
+				name := strings.Replace(course.Name, "/", "-", -1)
+				arc.Dir = filepath.Join(os.ExpandEnv(config.GetString("basedir")), "archive", name)
+			}
+			arc.Stdout = cmd.OutOrStdout()
+			if _, err = arc.Archive(course); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "archived to", filepath.Join(arc.Dir, "index.html"))
+			return internal.Errors.Finish(cmd.ErrOrStderr())
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVarP(&dir, "dir", "d", "", "directory to write the archive to")
+	flags.BoolVar(&arc.NoFiles, "no-files", false, "do not download the course files")
+	return cmd
+}
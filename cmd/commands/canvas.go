@@ -6,12 +6,17 @@ import (
 	"os"
 	"path"
 	"path/filepath"
-	"sync"
+	"sort"
+	"strconv"
 	"time"
 
+	"github.com/harrybrwn/config"
 	"github.com/harrybrwn/edu/cmd/internal"
+	"github.com/harrybrwn/edu/cmd/internal/files"
+	"github.com/harrybrwn/edu/cmd/internal/filesync"
 	"github.com/harrybrwn/edu/cmd/internal/opts"
-	"github.com/harrybrwn/edu/cmd/print"
+	"github.com/harrybrwn/edu/cmd/internal/planner"
+	"github.com/harrybrwn/edu/cmd/internal/submit"
 	"github.com/harrybrwn/edu/pkg/term"
 	"github.com/harrybrwn/go-canvas"
 	"github.com/jaytaylor/html2text"
@@ -74,15 +79,36 @@ func (dd dueDates) Less(i, j int) bool {
 	return dd[i].date.Before(dd[j].date)
 }
 
-func newDueCmd(flags *opts.Global) *cobra.Command {
-	var nolinks, all bool
+// dueLookBack is how far back edu due looks for overdue work.
+const dueLookBack = 30 * 24 * time.Hour
+
+func newDueCmd(globals *opts.Global) *cobra.Command {
+	var (
+		nolinks, all         bool
+		unsubmitted, missing bool
+		within               = "14d"
+		courseIDs            []string
+	)
 	dueCmd := &cobra.Command{
 		Use:   "due [id|name]",
-		Short: "List all the due date on canvas.",
+		Short: "List everything on your canvas to-do list.",
+		Long: `List everything on your canvas to-do list.
+
+Assignments, quizzes, graded discussions and planner notes from the
+canvas planner are shown in one list sorted by date. Work from the last
+30 days that is not done is shown as overdue. Items that have been
+submitted or marked as done are hidden unless --all is given.
+
+Given an assignment id or name, the assignment description is shown.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) > 0 {
 				as, err := internal.FindAssignment(args[0], all)
 				if err != nil {
+					// errors listing the assignments may be
+					// why it was not found
+					if e := internal.Errors.Finish(cmd.ErrOrStderr()); e != nil {
+						return e
+					}
 					return err
 				}
 				text, err := html2text.FromString(
@@ -97,42 +123,64 @@ func newDueCmd(flags *opts.Global) *cobra.Command {
 				}
 				cmd.Println(term.Colorf("%b %r", as.Name, as.DueAt.Local().String()), "Course ID:", as.CourseID)
 				cmd.Println(text)
-				return nil
+				return internal.Errors.Finish(cmd.ErrOrStderr())
 			}
 
-			courses, err := internal.GetCourses(false, canvas.Opt("order_by", "name"))
+			window, err := planner.ParseDuration(within)
+			if err != nil {
+				return err
+			}
+			filter := planner.Filter{All: all, Unsubmitted: unsubmitted, Missing: missing}
+			if len(courseIDs) > 0 {
+				filter.Courses = make(map[int]bool)
+				for _, id := range courseIDs {
+					course, err := internal.FindCourse(id)
+					if err != nil {
+						return internal.HandleAuthErr(err)
+					}
+					filter.Courses[course.ID] = true
+				}
+			}
+			now := time.Now()
+			items, err := planner.Items(now.Add(-dueLookBack), now.Add(window))
 			if err != nil {
 				return internal.HandleAuthErr(err)
 			}
+			sort.SliceStable(items, func(i, j int) bool {
+				return items[i].PlannableDate.Before(items[j].PlannableDate)
+			})
 
-			// if the user has not given a crn, then we print out all the assignments
-			var wg sync.WaitGroup
-			wg.Add(len(courses))
 			tab := internal.NewTable(cmd.OutOrStdout())
-			tab.SetAutoWrapText(false)
-			internal.SetTableHeader(tab, []string{"id", "name", "due"}, !flags.NoColor)
-			tab.SetAutoWrapText(true)
-			tab.SetColWidth(50)
-
-			printer := &print.AssignmentPrinter{
-				Writer:    cmd.OutOrStdout(),
-				WaitGroup: &wg,
-				Table:     tab,
-				Now:       time.Now(),
-			}
-			for _, course := range courses {
-				go printer.PrintCourseAssignments(course, all)
+			internal.SetTableHeader(tab, []string{"due", "date", "id", "type", "course", "title", "status"}, !globals.NoColor)
+			for _, it := range items {
+				if !filter.Keep(it) {
+					continue
+				}
+				tab.Append([]string{
+					planner.Relative(it, now),
+					it.PlannableDate.Local().Format("Mon Jan 02 15:04"),
+					strconv.Itoa(it.PlannableID),
+					it.Type(),
+					it.Course(),
+					it.Plannable.Title,
+					it.Status(),
+				})
 			}
-			wg.Wait()
-			return nil
+			tab.Render()
+			return internal.Errors.Finish(cmd.ErrOrStderr())
 		},
 	}
-	dueCmd.Flags().BoolVar(&nolinks, "no-links", false, "hide links from assignment description")
-	dueCmd.Flags().BoolVarP(&all, "all", "a", false, "show all the assignments")
+	flags := dueCmd.Flags()
+	flags.BoolVar(&nolinks, "no-links", false, "hide links from assignment description")
+	flags.BoolVarP(&all, "all", "a", false, "show items that are already done")
+	flags.StringVarP(&within, "within", "w", within, "only show items due within this much time (ex. 36h, 7d, 2w)")
+	flags.StringArrayVarP(&courseIDs, "course", "c", nil, "only show items from a course")
+	flags.BoolVarP(&unsubmitted, "unsubmitted", "u", false, "only show items that have not been submitted")
+	flags.BoolVarP(&missing, "missing", "m", false, "only show items that canvas says are missing")
 	return dueCmd
 }
 
-func newFilesCmd() *cobra.Command {
+func newFilesCmd(globals *opts.Global) *cobra.Command {
 	var (
 		sortby = []string{"created_at"}
 		ff     fileFinder
@@ -150,18 +198,13 @@ func newFilesCmd() *cobra.Command {
 			count := 0
 
 			for _, course := range courses {
-				course.SetErrorHandler(func(err error) error {
-					if err != nil {
-						fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
-					}
-					return nil
-				})
 				if course.AccessRestrictedByDate {
 					fmt.Fprintf(
 						os.Stderr, "Access to %d %s has been restricted to a certain date\n",
 						course.ID, course.Name)
 					continue
 				}
+				course.SetErrorHandler(internal.Errors.Handler(course))
 				files := course.Files(opts...)
 				for f := range files {
 					cmd.Println(f.CreatedAt, f.Size, f.Filename)
@@ -169,12 +212,140 @@ func newFilesCmd() *cobra.Command {
 				}
 			}
 			cmd.Println(count, "files total.")
-			return nil
+			return internal.Errors.Finish(cmd.ErrOrStderr())
 		},
 	}
 	flags := c.Flags()
 	flags.StringArrayVarP(&sortby, "sortyby", "s", sortby, "how the files should be sorted")
 	ff.addToFlagSet(flags)
+	c.AddCommand(newFileHistoryCmd(globals), newFileSyncCmd())
+	return c
+}
+
+func newFileSyncCmd() *cobra.Command {
+	var (
+		dryrun   bool
+		download bool
+	)
+	c := &cobra.Command{
+		Use:   "sync <local-dir> <canvas-folder>",
+		Short: "Sync a local directory with a folder in your canvas files.",
+		Long: `Sync a local directory with a folder in your canvas files.
+
+New and changed local files are uploaded and missing folders are
+created on canvas. Files that only exist or have only changed on
+canvas are downloaded when --download is given. The state of every
+file after a sync is saved in the local directory so that files
+changed on both sides can be found, these conflicts are left alone.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s := &filesync.Syncer{
+				Dir:      args[0],
+				Folder:   args[1],
+				Download: download,
+				Stdout:   cmd.OutOrStdout(),
+			}
+			if stat, err := os.Stat(s.Dir); err != nil {
+				return err
+			} else if !stat.IsDir() {
+				return fmt.Errorf("%s is not a directory", s.Dir)
+			}
+			plan, state, err := s.Plan()
+			if err != nil {
+				return internal.HandleAuthErr(err)
+			}
+			if dryrun {
+				_, err = plan.WriteTo(cmd.OutOrStdout())
+				return err
+			}
+			for _, a := range plan {
+				if a.Op == filesync.Conflict || a.Op == filesync.Skip {
+					cmd.Printf("%-8s %s (%s)\n", a.Op, a.Path, a.Reason)
+				}
+			}
+			failed, err := s.Apply(plan, state)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("%d uploaded, %d downloaded, %d conflicts, %d failed\n",
+				plan.Count(filesync.Upload), plan.Count(filesync.Download),
+				plan.Count(filesync.Conflict), failed)
+			if failed > 0 {
+				return &internal.Error{Msg: fmt.Sprintf("%d files failed to sync", failed), Code: 1}
+			}
+			return nil
+		},
+	}
+	flags := c.Flags()
+	flags.BoolVarP(&dryrun, "dry-run", "n", false, "only print what would be done")
+	flags.BoolVar(&download, "download", false, "download files that are new or changed on canvas")
+	return c
+}
+
+func newFileHistoryCmd(globals *opts.Global) *cobra.Command {
+	var (
+		restore = -1
+		basedir = os.ExpandEnv(config.GetString("basedir"))
+	)
+	c := &cobra.Command{
+		Use:   "history <file>",
+		Short: "List and restore previous versions of a downloaded file.",
+		Long: `List and restore previous versions of a downloaded file.
+
+Old versions are only saved when 'edu update' is run with
+--keep-versions or when 'keep_versions' is set in the config.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			manifest, err := files.OpenManifest(basedir)
+			if err != nil {
+				return err
+			}
+			path := args[0]
+			if _, err = os.Stat(path); err == nil {
+				if path, err = filepath.Abs(path); err != nil {
+					return err
+				}
+			}
+			entry, err := manifest.Find(path)
+			if err != nil {
+				return err
+			}
+			if restore >= 0 {
+				if err = manifest.Restore(entry.ID, restore); err != nil {
+					return err
+				}
+				if err = manifest.Save(); err != nil {
+					return err
+				}
+				cmd.Printf("restored version %d of %s\n", restore, entry.Path)
+				return nil
+			}
+
+			tab := internal.NewTable(cmd.OutOrStdout())
+			internal.SetTableHeader(tab, []string{"version", "updated", "saved", "size", "path"}, !globals.NoColor)
+			for i, v := range entry.Versions {
+				tab.Append([]string{
+					strconv.Itoa(i),
+					v.UpdatedAt.Local().Format(time.RFC822),
+					v.SavedAt.Local().Format(time.RFC822),
+					strconv.FormatInt(v.Size, 10),
+					v.Path,
+				})
+			}
+			tab.Append([]string{
+				"current",
+				entry.UpdatedAt.Local().Format(time.RFC822),
+				"",
+				strconv.FormatInt(entry.Size, 10),
+				entry.Path,
+			})
+			tab.Render()
+			return nil
+		},
+	}
+	flags := c.Flags()
+	flags.IntVarP(&restore, "restore", "r", restore, "restore a version of the file")
+	flags.StringVar(&basedir, "base-dir", basedir, "base directory for file downloads")
 	return c
 }
 
@@ -184,6 +355,7 @@ func newUploadCmd() *cobra.Command {
 		folderPath   string
 		uploadAs     string
 		assignmentID int
+		force        bool
 	)
 	c := &cobra.Command{
 		Use:   "upload <file>",
@@ -205,6 +377,17 @@ func newUploadCmd() *cobra.Command {
 					return err
 				}
 				defer f.Close()
+				stat, err := f.Stat()
+				if err != nil {
+					return err
+				}
+				_, err = preflight(cmd, as, submit.OnlineUpload, []submit.File{{Name: stat.Name(), Size: stat.Size()}}, force)
+				if err != nil {
+					return err
+				}
+				if err = confirmSubmit(cmd); err != nil {
+					return err
+				}
 				cafile, err := as.SubmitOsFile(f)
 				if err != nil {
 					return err
@@ -238,9 +421,43 @@ func newUploadCmd() *cobra.Command {
 	flags.StringVarP(&uploadAs, "upload-as", "u", "", "rename the file being uploaded")
 	flags.StringVarP(&folderPath, "folder", "d", "", "set the folder path to upload the file to")
 	flags.IntVarP(&assignmentID, "assignment-id", "a", assignmentID, "upload the file as an assignment submission")
+	flags.BoolVar(&force, "force", false, "submit even if the assignment checks fail")
 	return c
 }
 
+// preflight checks a submission and prints a summary. Failed
+// checks are an error unless force is true.
+func preflight(cmd *cobra.Command, as *canvas.Assignment, typ string, files []submit.File, force bool) (*submit.Assignment, error) {
+	details, err := submit.GetAssignment(as.CourseID, as.ID)
+	if err != nil {
+		return nil, internal.HandleAuthErr(err)
+	}
+	var quota *submit.Quota
+	if typ == submit.OnlineUpload {
+		if quota, err = submit.GetQuota(); err != nil {
+			// not worth failing over
+			quota = nil
+		}
+	}
+	p := submit.Check(details, typ, files, quota, time.Now())
+	if _, err = p.WriteTo(cmd.OutOrStdout()); err != nil {
+		return nil, err
+	}
+	if !p.OK() && !force {
+		return nil, &internal.Error{Msg: "submission failed checks (use --force to submit anyway)", Code: 1}
+	}
+	return details, nil
+}
+
+// confirmSubmit asks the user to confirm a
+// submission when stdin is a terminal.
+func confirmSubmit(cmd *cobra.Command) error {
+	if term.IsTerminal(os.Stdin) && !submit.Confirm(os.Stdin, cmd.OutOrStdout(), "Submit?") {
+		return &internal.Error{Msg: "submission canceled", Code: 1}
+	}
+	return nil
+}
+
 func upload(filename, uploadname string) (err error) {
 	dir, uploadname := filepath.Split(uploadname)
 	file, err := os.Open(filename)
@@ -13,6 +13,7 @@ import (
 	"github.com/harrybrwn/config"
 	"github.com/harrybrwn/edu/cmd/internal"
 	"github.com/harrybrwn/edu/cmd/internal/files"
+	"github.com/harrybrwn/edu/cmd/internal/grades"
 	"github.com/harrybrwn/edu/cmd/internal/opts"
 	"github.com/harrybrwn/edu/pkg/term"
 	"github.com/harrybrwn/go-canvas"
@@ -29,6 +30,12 @@ type Config struct {
 	BaseDir       string `yaml:"basedir" default:"$HOME/.edu/files"`
 	Token         string `yaml:"token" env:"CANVAS_TOKEN"`
 	Notifications bool   `yaml:"notifications" default:"true"`
+	KeepVersions  bool   `yaml:"keep_versions"`
+	Jobs          int    `yaml:"jobs" default:"4"`
+	PathTemplate  string `yaml:"path_template"`
+	Markdown      bool   `yaml:"export_markdown"`
+	Submissions   bool   `yaml:"download_submissions"`
+	SearchIndex   bool   `yaml:"search_index" default:"true"`
 
 	Twilio struct {
 		SID    string `yaml:"sid" env:"TWILIO_SID"`
@@ -39,6 +46,11 @@ type Config struct {
 		Term string `yaml:"term"`
 		Year int    `yaml:"year"`
 	} `yaml:"registration"`
+	GPA struct {
+		Scale        grades.Scale       `yaml:"scale"`
+		Units        map[string]float64 `yaml:"units"`
+		DefaultUnits float64            `yaml:"default_units"`
+	} `yaml:"gpa"`
 	Watch struct {
 		Duration     string `yaml:"duration" default:"12h"`
 		CRNs         []int  `yaml:"crns"`
@@ -51,6 +63,10 @@ type Config struct {
 	} `yaml:"watch"`
 	Replacements       []files.Replacement            `yaml:"replacements"`
 	CourseReplacements map[string][]files.Replacement `yaml:"course-replacements"`
+	Hooks              []files.Hook                   `yaml:"hooks"`
+	CourseHooks        map[string][]files.Hook        `yaml:"course-hooks"`
+	Filters            files.Filters                  `yaml:"filters"`
+	CourseFilters      map[string]files.Filters       `yaml:"course-filters"`
 }
 
 // All returns all the commands.
@@ -62,10 +78,20 @@ func All(globals *opts.Global) []*cobra.Command {
 		newUserCmd(),
 
 		newDueCmd(globals),
-		newFilesCmd(),
+		newDoneCmd(),
+		newUndoneCmd(),
+		newNoteCmd(),
+		newFilesCmd(globals),
 		newUploadCmd(),
+		newSubmitCmd(),
+		newSubmissionsCmd(globals),
+		newGradesCmd(globals),
+		newGPACmd(globals),
+		newAnnouncementsCmd(globals),
 
-		newUpdateCmd(),
+		newUpdateCmd(globals),
+		newArchiveCmd(),
+		newSearchCmd(globals),
 		newRegistrationCmd(globals),
 		newTextCmd(),
 	}
@@ -182,6 +208,8 @@ func newCourseCmd(globals *opts.Global) *cobra.Command {
 					tab.Append([]string{d.id, d.name, d.date.Format(time.RFC822)})
 				}
 				tab.Render()
+				// the assignment listing stops at the first error
+				return internal.Errors.Finish(cmd.ErrOrStderr())
 			} else {
 				cmd.Printf("Download calendar: %s\n", course.Calendar.ICSDownload)
 				cmd.Printf("Start: %v\nEnd: %v\n", course.CreatedAt, course.EndAt)
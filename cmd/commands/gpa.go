@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/harrybrwn/edu/cmd/internal"
+	"github.com/harrybrwn/edu/cmd/internal/files"
+	"github.com/harrybrwn/edu/cmd/internal/grades"
+	"github.com/harrybrwn/edu/cmd/internal/opts"
+	"github.com/spf13/cobra"
+)
+
+func newGPACmd(globals *opts.Global) *cobra.Command {
+	var (
+		all       bool
+		final     bool
+		scenarios []string
+	)
+	c := &cobra.Command{
+		Use:   "gpa",
+		Short: "Calculate your GPA.",
+		Long: `Calculate your GPA for each term and your cumulative GPA.
+
+Course grades are mapped to grade points with the 'gpa.scale' from
+the config and each course is weighted by its units from 'gpa.units'.
+Courses that are not in 'gpa.units' count for 'gpa.default_units'.
+
+Use --scenario to see what a projected grade would do to your GPA. A
+scenario is written as '<course>=<grade>[,<units>]' where the course is
+a course code or name and the grade is a letter or percent. Courses that
+are not found are added as new courses (ex. 'CSE 100=A-,4').`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scale := Conf.GPA.Scale
+			if len(scale) == 0 {
+				scale = grades.DefaultScale
+			}
+			if err := scale.Validate(); err != nil {
+				return err
+			}
+			defaultUnits := Conf.GPA.DefaultUnits
+			if defaultUnits <= 0 {
+				defaultUnits = 1
+			}
+			courses, err := internal.GetCourses(all)
+			if err != nil {
+				return internal.HandleAuthErr(err)
+			}
+			enrollments, err := grades.Enrollments(all)
+			if err != nil {
+				return internal.HandleAuthErr(err)
+			}
+			byCourse := make(map[int]grades.Enrollment)
+			for _, e := range enrollments {
+				byCourse[e.CourseID] = e
+			}
+
+			var (
+				wg     sync.WaitGroup
+				mu     sync.Mutex
+				graded []grades.CourseGrade
+			)
+			for _, course := range courses {
+				e, ok := byCourse[course.ID]
+				if !ok {
+					continue
+				}
+				cg := grades.CourseGrade{
+					Code:    course.CourseCode,
+					Name:    course.Name,
+					Percent: e.Grades.CurrentScore,
+					Letter:  e.Grades.CurrentGrade,
+					Units:   grades.Units(Conf.GPA.Units, course.CourseCode, defaultUnits),
+				}
+				if final {
+					cg.Percent, cg.Letter = e.Grades.FinalScore, e.Grades.FinalGrade
+				}
+				internal.Errors.Attempt(course.Name)
+				wg.Add(1)
+				go func(id int, cg grades.CourseGrade) {
+					defer wg.Done()
+					term, err := files.CourseTerm(id)
+					if err != nil {
+						internal.Errors.Add(cg.Name, err)
+					} else {
+						cg.Term, cg.TermStart = term.Name, term.StartAt
+					}
+					mu.Lock()
+					graded = append(graded, cg)
+					mu.Unlock()
+				}(course.ID, cg)
+			}
+			wg.Wait()
+			sort.Slice(graded, func(i, j int) bool {
+				if !graded[i].TermStart.Equal(graded[j].TermStart) {
+					return graded[i].TermStart.Before(graded[j].TermStart)
+				}
+				return graded[i].Code < graded[j].Code
+			})
+			if graded, err = scale.Scenario(graded, scenarios, defaultUnits); err != nil {
+				return err
+			}
+
+			tab := internal.NewTable(cmd.OutOrStdout())
+			internal.SetTableHeader(tab, []string{"term", "code", "course", "grade", "points", "units"}, !globals.NoColor)
+			for i := range graded {
+				cg := &graded[i]
+				letter, pts, ok := scale.Resolve(cg)
+				row := []string{cg.Term, cg.Code, cg.Name, "-", "-", strconv.FormatFloat(cg.Units, 'f', -1, 64)}
+				if cg.Percent != nil && cg.Letter == "" {
+					row[3] = fmt.Sprintf("%s (%.2f%%)", letter, *cg.Percent)
+				} else if cg.Letter != "" {
+					row[3] = cg.Letter
+				}
+				if ok {
+					row[4] = fmt.Sprintf("%.2f", pts)
+				}
+				if cg.Projected {
+					row[3] += " *"
+				}
+				tab.Append(row)
+			}
+			tab.Render()
+
+			terms, total := scale.Compute(graded)
+			cmd.Println()
+			tab = internal.NewTable(cmd.OutOrStdout())
+			internal.SetTableHeader(tab, []string{"term", "courses", "units", "gpa"}, !globals.NoColor)
+			for _, g := range append(terms, total) {
+				tab.Append([]string{
+					g.Term,
+					strconv.Itoa(g.Courses),
+					strconv.FormatFloat(g.Units, 'f', -1, 64),
+					fmt.Sprintf("%.3f", g.Value()),
+				})
+			}
+			tab.Render()
+			if len(scenarios) > 0 {
+				cmd.Println("\n* projected grade")
+			}
+			return internal.Errors.Finish(cmd.ErrOrStderr())
+		},
+	}
+	flags := c.Flags()
+	flags.BoolVarP(&all, "all", "a", false, "include courses from past terms")
+	flags.BoolVar(&final, "final", false, "use final grades (ungraded work counts as zero) instead of current grades")
+	flags.StringArrayVarP(&scenarios, "scenario", "s", nil, "a projected grade as <course>=<grade>[,<units>]")
+	return c
+}
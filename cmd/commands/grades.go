@@ -0,0 +1,176 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/harrybrwn/edu/cmd/internal"
+	"github.com/harrybrwn/edu/cmd/internal/grades"
+	"github.com/harrybrwn/edu/cmd/internal/opts"
+	"github.com/harrybrwn/edu/pkg/term"
+	"github.com/spf13/cobra"
+)
+
+func newGradesCmd(globals *opts.Global) *cobra.Command {
+	var (
+		whatIf     []string
+		whatIfFile string
+	)
+	c := &cobra.Command{
+		Use:   "grades [course]",
+		Short: "Show your grades.",
+		Long: `Show your grades.
+
+With no arguments, the current and final grades for all of your courses
+are listed. Given a course, the grade for each assignment group is shown
+along with the group weights.
+
+Use --what-if to see how hypothetical scores would change the course
+grade. Scores are given as '<assignment>=<score>' where the assignment is
+an id or name and the score is points or a percent (ex. 'Final=85%').
+A file with one score on each line can be given with --what-if-file.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			enrollments, err := grades.Enrollments(false)
+			if err != nil {
+				return internal.HandleAuthErr(err)
+			}
+			if whatIfFile != "" {
+				f, err := os.Open(whatIfFile)
+				if err != nil {
+					return err
+				}
+				specs, err := grades.ReadWhatIf(f)
+				f.Close()
+				if err != nil {
+					return err
+				}
+				whatIf = append(whatIf, specs...)
+			}
+			if len(args) == 0 {
+				if len(whatIf) > 0 {
+					return errors.New("what-if scores need a course")
+				}
+				return printGrades(cmd.OutOrStdout(), enrollments, globals)
+			}
+
+			course, err := internal.FindCourse(args[0])
+			if err != nil {
+				return internal.HandleAuthErr(err)
+			}
+			groups, weighted, err := grades.Groups(course.ID)
+			if err != nil {
+				return internal.HandleAuthErr(err)
+			}
+			name := course.Name
+			if !globals.NoColor {
+				name = term.Colorf("%m", name)
+			}
+			cmd.Printf("%d %s\n", course.ID, name)
+			for _, e := range enrollments {
+				if e.CourseID == course.ID {
+					cmd.Printf("current: %s\nfinal:   %s\n\n",
+						grade(e.Grades.CurrentScore, e.Grades.CurrentGrade),
+						grade(e.Grades.FinalScore, e.Grades.FinalGrade))
+					break
+				}
+			}
+
+			res := grades.Compute(groups, weighted, nil)
+			printGroups(cmd.OutOrStdout(), res, globals)
+			cmd.Printf("\ncalculated: %s\n", grade(res.Percent, ""))
+			if len(whatIf) == 0 {
+				return nil
+			}
+			scores, err := grades.WhatIf(whatIf, groups)
+			if err != nil {
+				return err
+			}
+			whatIfRes := grades.Compute(groups, weighted, scores)
+			cmd.Println("\nwhat-if:")
+			printGroups(cmd.OutOrStdout(), whatIfRes, globals)
+			cmd.Printf("\nwhat-if: %s", grade(whatIfRes.Percent, ""))
+			if res.Percent != nil && whatIfRes.Percent != nil {
+				cmd.Printf(" (%+.2f%%)", *whatIfRes.Percent-*res.Percent)
+			}
+			cmd.Println()
+			return nil
+		},
+	}
+	flags := c.Flags()
+	flags.StringArrayVarP(&whatIf, "what-if", "w", nil, "a hypothetical score as <assignment>=<score>")
+	flags.StringVar(&whatIfFile, "what-if-file", "", "read what-if scores from a file")
+	return c
+}
+
+func printGrades(w io.Writer, enrollments []grades.Enrollment, globals *opts.Global) error {
+	courses, err := internal.GetCourses(false)
+	if err != nil {
+		return internal.HandleAuthErr(err)
+	}
+	tab := internal.NewTable(w)
+	internal.SetTableHeader(tab, []string{"id", "course", "code", "current", "final"}, !globals.NoColor)
+	for _, course := range courses {
+		for _, e := range enrollments {
+			if e.CourseID != course.ID {
+				continue
+			}
+			tab.Append([]string{
+				strconv.Itoa(course.ID),
+				course.Name,
+				course.CourseCode,
+				grade(e.Grades.CurrentScore, e.Grades.CurrentGrade),
+				grade(e.Grades.FinalScore, e.Grades.FinalGrade),
+			})
+			break
+		}
+	}
+	tab.Render()
+	return nil
+}
+
+func printGroups(w io.Writer, res *grades.Result, globals *opts.Global) {
+	header := []string{"group", "points", "percent", "dropped"}
+	if res.Weighted {
+		header = []string{"group", "weight", "points", "percent", "dropped"}
+	}
+	tab := internal.NewTable(w)
+	internal.SetTableHeader(tab, header, !globals.NoColor)
+	for _, g := range res.Groups {
+		row := []string{g.Group.Name}
+		if res.Weighted {
+			row = append(row, fmt.Sprintf("%g%%", g.Group.Weight))
+		}
+		pct := "-"
+		if g.Percent != nil {
+			pct = fmt.Sprintf("%.2f%%", *g.Percent)
+		}
+		dropped := ""
+		if len(g.Dropped) > 0 {
+			dropped = strconv.Itoa(len(g.Dropped))
+		}
+		earned := g.Earned
+		row = append(row, grades.Score(&earned, g.Possible), pct, dropped)
+		tab.Append(row)
+	}
+	tab.Render()
+}
+
+// grade formats a percent with its letter grade. The scale
+// from the config is used if canvas did not give a letter.
+func grade(percent *float64, letter string) string {
+	if percent == nil {
+		return "-"
+	}
+	if letter == "" {
+		scale := Conf.GPA.Scale
+		if len(scale) == 0 {
+			scale = grades.DefaultScale
+		}
+		letter = scale.Letter(*percent)
+	}
+	return fmt.Sprintf("%.2f%% (%s)", *percent, letter)
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/harrybrwn/edu/cmd/internal"
+	"github.com/harrybrwn/edu/cmd/internal/planner"
+	"github.com/spf13/cobra"
+)
+
+// plannerWindow is how far ahead edu done looks for items.
+const plannerWindow = 180 * 24 * time.Hour
+
+func newDoneCmd() *cobra.Command {
+	return newCompleteCmd("done", true)
+}
+
+func newUndoneCmd() *cobra.Command {
+	return newCompleteCmd("undone", false)
+}
+
+func newCompleteCmd(name string, complete bool) *cobra.Command {
+	short := "Mark an item on your to-do list as done."
+	if !complete {
+		short = "Mark an item on your to-do list as not done."
+	}
+	return &cobra.Command{
+		Use:   name + " <id|title>",
+		Short: short,
+		Long: short + `
+
+The item is found by the id or title shown by 'edu due' and is marked
+the same way as the check box on the canvas dashboard. Use <type>:<id>
+(ex. quiz:12) when items of different types have the same id.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			now := time.Now()
+			items, err := planner.Items(now.Add(-dueLookBack), now.Add(plannerWindow))
+			if err != nil {
+				return internal.HandleAuthErr(err)
+			}
+			it, err := planner.Find(items, args[0])
+			if err != nil {
+				return err
+			}
+			if _, err = planner.SetComplete(it, complete); err != nil {
+				return internal.HandleAuthErr(err)
+			}
+			cmd.Printf("marked %q as %s\n", it.Plannable.Title, name)
+			return nil
+		},
+	}
+}
+
+func newNoteCmd() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "note",
+		Short: "Manage planner notes.",
+	}
+	var (
+		course  string
+		date    string
+		details string
+	)
+	add := &cobra.Command{
+		Use:   "add <text>",
+		Short: "Add a note to your canvas planner.",
+		Long: `Add a note to your canvas planner.
+
+The note shows up in 'edu due' and on the canvas dashboard. The date
+can be a date (2020-10-31), a date and time (2020-10-31 15:04), today,
+tomorrow, or a duration from now (3d), the default is today.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			todo, err := planner.ParseDate(date, time.Now())
+			if err != nil {
+				return err
+			}
+			var courseID int
+			if course != "" {
+				crs, err := internal.FindCourse(course)
+				if err != nil {
+					return internal.HandleAuthErr(err)
+				}
+				courseID = crs.ID
+			}
+			note, err := planner.CreateNote(args[0], details, todo, courseID)
+			if err != nil {
+				return internal.HandleAuthErr(err)
+			}
+			cmd.Printf("added note %d for %s\n", note.ID, todo.Local().Format(time.RFC822))
+			return nil
+		},
+	}
+	flags := add.Flags()
+	flags.StringVarP(&course, "course", "c", "", "the course the note is for")
+	flags.StringVarP(&date, "date", "d", "", "the date of the note")
+	flags.StringVar(&details, "details", "", "more details for the note")
+	c.AddCommand(add)
+	return c
+}
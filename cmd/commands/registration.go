@@ -264,19 +264,34 @@ func watchFiles() error {
 	}
 	courseReps := upperMapKeys(Conf.CourseReplacements)
 	dl := files.NewDownloader(basedir)
+	dl.Jobs = config.GetInt("jobs")
+	dl.Hooks = Conf.Hooks
+	dl.CourseHooks = Conf.CourseHooks
+	if err = setFilters(dl); err != nil {
+		return err
+	}
+	if tmpl := config.GetString("path_template"); tmpl != "" {
+		if dl.PathTemplate, err = files.ParseTemplate(tmpl); err != nil {
+			return err
+		}
+	}
+	dl.Manifest, err = files.OpenManifest(basedir)
+	if err != nil {
+		return err
+	}
 	for _, course := range courses {
 		if course.AccessRestrictedByDate {
 			continue
 		}
-		reps, ok := courseReps[course.CourseCode]
-		if !ok {
-			reps = Conf.Replacements
-		} else {
-			reps = append(Conf.Replacements, reps...)
-		}
-		dl.Download(course, reps)
+		dl.Download(course, courseReplacements(courseReps, course))
 	}
 	dl.Wait()
+	if err = dl.Manifest.Save(); err != nil {
+		return err
+	}
+	if config.GetBool("search_index") {
+		return updateIndex(basedir)
+	}
 	return nil
 }
 
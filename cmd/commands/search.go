@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/harrybrwn/config"
+	"github.com/harrybrwn/edu/cmd/internal/opts"
+	"github.com/harrybrwn/edu/cmd/internal/search"
+	"github.com/harrybrwn/edu/pkg/term"
+	"github.com/spf13/cobra"
+)
+
+func newSearchCmd(globals *opts.Global) *cobra.Command {
+	var (
+		limit   = 10
+		course  string
+		basedir = os.ExpandEnv(config.GetString("basedir"))
+	)
+	cmd := &cobra.Command{
+		Use:   "search <query>",
+		Short: "Search the text of your downloaded files",
+		Long: `Search the text of your downloaded files.
+
+The search index has the text of the pdf, html, markdown, and text
+files in the base directory. It is updated by 'edu update' and before
+every search.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ix, err := search.Open(basedir)
+			if err != nil {
+				return err
+			}
+			if _, _, err = ix.Update(); err != nil {
+				return err
+			}
+			if err = ix.Save(); err != nil {
+				return err
+			}
+			query := strings.Join(args, " ")
+			out := cmd.OutOrStdout()
+			n := 0
+			for _, hit := range ix.Search(query) {
+				if course != "" && !strings.Contains(strings.ToLower(hit.Course), strings.ToLower(course)) {
+					continue
+				}
+				if limit > 0 && n >= limit {
+					break
+				}
+				n++
+				path := hit.Path
+				if !globals.NoColor {
+					path = term.Colorf("%m", path)
+				}
+				fmt.Fprintf(out, "%s (%s) %.2f\n", path, hit.Course, hit.Score)
+				if snippet := ix.Snippet(hit.Doc, query, 160); snippet != "" {
+					fmt.Fprintf(out, "    %s\n", snippet)
+				}
+			}
+			if n == 0 {
+				fmt.Fprintln(out, "no results")
+			}
+			return nil
+		},
+	}
+	flags := cmd.Flags()
+	flags.IntVarP(&limit, "limit", "n", limit, "maximum number of results (0 means no limit)")
+	flags.StringVarP(&course, "course", "c", "", "only show results from a course")
+	flags.StringVar(&basedir, "base-dir", basedir, "base directory for file downloads")
+	return cmd
+}
@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/harrybrwn/edu/cmd/internal"
+	"github.com/harrybrwn/edu/cmd/internal/grades"
+	"github.com/harrybrwn/edu/cmd/internal/opts"
+	"github.com/harrybrwn/edu/pkg/term"
+	"github.com/harrybrwn/go-canvas"
+	"github.com/spf13/cobra"
+)
+
+func newSubmissionsCmd(globals *opts.Global) *cobra.Command {
+	var assignment bool
+	c := &cobra.Command{
+		Use:   "submissions [course|assignment]",
+		Short: "List your submissions and how they were graded.",
+		Long: `List your submissions and how they were graded.
+
+With no arguments, the submissions for all of your current courses are
+listed. Given a course, only that course is listed. Given an assignment,
+every attempt, the grader's comments and the rubric are shown.`,
+		Aliases: []string{"subs"},
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var courses []*canvas.Course
+			if len(args) > 0 {
+				if !assignment {
+					course, err := internal.FindCourse(args[0])
+					switch {
+					case err == nil:
+						courses = append(courses, course)
+					case err != internal.ErrCourseNotFound &&
+						internal.Classify(err) != internal.NotFoundErr:
+						// only look for an assignment when
+						// the course does not exist
+						return internal.HandleAuthErr(err)
+					}
+				}
+				if len(courses) == 0 {
+					as, err := internal.FindAssignment(args[0], false)
+					if err != nil {
+						// errors listing the assignments may be
+						// why it was not found
+						if e := internal.Errors.Finish(cmd.ErrOrStderr()); e != nil {
+							return e
+						}
+						return internal.HandleAuthErr(err)
+					}
+					details, err := grades.GetSubmission(as.CourseID, as.ID)
+					if err != nil {
+						return internal.HandleAuthErr(err)
+					}
+					printSubmission(cmd.OutOrStdout(), details, globals)
+					return internal.Errors.Finish(cmd.ErrOrStderr())
+				}
+			} else {
+				var err error
+				if courses, err = internal.GetCourses(false); err != nil {
+					return internal.HandleAuthErr(err)
+				}
+			}
+
+			var (
+				wg   sync.WaitGroup
+				subs = make([][]*grades.Submission, len(courses))
+			)
+			for i, course := range courses {
+				if course.AccessRestrictedByDate {
+					continue
+				}
+				internal.Errors.Attempt(course.Name)
+				wg.Add(1)
+				go func(i int, course *canvas.Course) {
+					defer wg.Done()
+					s, err := grades.Submissions(course.ID)
+					if err != nil {
+						internal.Errors.Add(course.Name, err)
+						return
+					}
+					subs[i] = s
+				}(i, course)
+			}
+			wg.Wait()
+			for i, course := range courses {
+				if len(subs[i]) == 0 {
+					continue
+				}
+				name := course.Name
+				if !globals.NoColor {
+					name = term.Colorf("%m", name)
+				}
+				cmd.Printf("%d %s\n", course.ID, name)
+				printSubmissions(cmd.OutOrStdout(), subs[i], globals)
+				cmd.Println()
+			}
+			return internal.Errors.Finish(cmd.ErrOrStderr())
+		},
+	}
+	c.Flags().BoolVarP(&assignment, "assignment", "a", false, "treat the argument as an assignment")
+	return c
+}
+
+func printSubmissions(w io.Writer, subs []*grades.Submission, globals *opts.Global) {
+	tab := internal.NewTable(w)
+	internal.SetTableHeader(tab, []string{"id", "assignment", "attempt", "submitted", "status", "score", "comments", "rubric"}, !globals.NoColor)
+	for _, s := range subs {
+		var (
+			name     string
+			id       = strconv.Itoa(s.AssignmentID)
+			possible float64
+			rubric   string
+		)
+		if s.Assignment != nil {
+			name = s.Assignment.Name
+			possible = s.Assignment.PointsPossible
+			if len(s.Assignment.Rubric) > 0 {
+				rubric = fmt.Sprintf("%d/%d", len(s.RubricAssessment), len(s.Assignment.Rubric))
+			}
+		}
+		tab.Append([]string{
+			id,
+			name,
+			attempt(s),
+			submittedAt(s),
+			s.Status(),
+			grades.Score(s.Score, possible),
+			strconv.Itoa(len(s.Comments)),
+			rubric,
+		})
+	}
+	tab.Render()
+}
+
+func printSubmission(w io.Writer, as *grades.Assignment, globals *opts.Global) {
+	name := as.Name
+	if !globals.NoColor {
+		name = term.Colorf("%m", name)
+	}
+	fmt.Fprintf(w, "%d %s\n", as.ID, name)
+	sub := as.Submission
+	if as.DueAt != nil {
+		fmt.Fprintf(w, "due:    %s\n", as.DueAt.Local().Format(time.RFC822))
+	}
+	fmt.Fprintf(w, "status: %s\n", sub.Status())
+	fmt.Fprintf(w, "score:  %s", grades.Score(sub.Score, as.PointsPossible))
+	if sub.Grade != "" && sub.Score != nil && sub.Grade != strconv.FormatFloat(*sub.Score, 'f', -1, 64) {
+		fmt.Fprintf(w, " (%s)", sub.Grade)
+	}
+	fmt.Fprintln(w)
+
+	history := sub.History
+	if len(history) == 0 {
+		history = []grades.Submission{*sub}
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].Attempt < history[j].Attempt })
+	fmt.Fprintln(w)
+	tab := internal.NewTable(w)
+	internal.SetTableHeader(tab, []string{"attempt", "submitted", "status", "score"}, !globals.NoColor)
+	for i := range history {
+		h := &history[i]
+		tab.Append([]string{attempt(h), submittedAt(h), h.Status(), grades.Score(h.Score, as.PointsPossible)})
+	}
+	tab.Render()
+
+	if len(sub.Comments) > 0 {
+		fmt.Fprintln(w, "\ncomments:")
+		for _, c := range sub.Comments {
+			fmt.Fprintf(w, "  %s (%s): %s\n", c.AuthorName, c.CreatedAt.Local().Format(time.RFC822), c.Comment)
+		}
+	}
+	if len(as.Rubric) > 0 {
+		fmt.Fprintln(w)
+		tab = internal.NewTable(w)
+		internal.SetTableHeader(tab, []string{"criterion", "rating", "points", "comments"}, !globals.NoColor)
+		for _, row := range grades.Rubric(as.Rubric, sub.RubricAssessment) {
+			tab.Append([]string{row.Criterion, row.Rating, grades.Score(row.Points, row.Possible), row.Comments})
+		}
+		tab.Render()
+	}
+}
+
+func attempt(s *grades.Submission) string {
+	if s.Attempt == 0 {
+		return ""
+	}
+	return strconv.Itoa(s.Attempt)
+}
+
+func submittedAt(s *grades.Submission) string {
+	if s.SubmittedAt == nil {
+		return ""
+	}
+	return s.SubmittedAt.Local().Format(time.RFC822)
+}
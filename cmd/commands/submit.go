@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/harrybrwn/config"
+	"github.com/harrybrwn/edu/cmd/internal"
+	"github.com/harrybrwn/edu/cmd/internal/submit"
+	"github.com/harrybrwn/edu/pkg/markdown"
+	"github.com/spf13/cobra"
+)
+
+func newSubmitCmd() *cobra.Command {
+	var (
+		text    bool
+		link    string
+		force   bool
+		basedir = os.ExpandEnv(config.GetString("basedir"))
+	)
+	c := &cobra.Command{
+		Use:   "submit <assignment-id> [files...]",
+		Short: "Submit an assignment.",
+		Long: `Submit an assignment.
+
+Files given as arguments are uploaded together as one submission. Use
+--text to write a text entry in markdown with the editor from the
+config (or $EDITOR), it is converted to html before it is sent. Use
+--url to submit a website url.
+
+The assignment settings are checked before anything is sent and every
+submission is recorded in the base directory.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.Atoi(args[0])
+			if err != nil {
+				return fmt.Errorf("invalid assignment id %q", args[0])
+			}
+			paths := args[1:]
+			var typ string
+			switch {
+			case link != "" && (text || len(paths) > 0), text && len(paths) > 0:
+				return errors.New("only one of files, --text, or --url can be submitted")
+			case link != "":
+				typ = submit.OnlineURL
+			case text:
+				typ = submit.OnlineTextEntry
+			case len(paths) > 0:
+				typ = submit.OnlineUpload
+			default:
+				return errors.New("nothing to submit: give some files, --text, or --url")
+			}
+
+			var files []submit.File
+			for _, p := range paths {
+				stat, err := os.Stat(p)
+				if err != nil {
+					return err
+				}
+				if stat.IsDir() {
+					return fmt.Errorf("%s is a directory", p)
+				}
+				files = append(files, submit.File{Name: stat.Name(), Size: stat.Size()})
+			}
+			as, err := internal.GetAssignment(id, false)
+			if err != nil {
+				return internal.HandleAuthErr(err)
+			}
+			details, err := preflight(cmd, as, typ, files, force)
+			if err != nil {
+				return err
+			}
+			var body string
+			if typ == submit.OnlineTextEntry {
+				md, err := submit.Edit(config.GetString("editor"), "")
+				if err != nil {
+					return err
+				}
+				body = markdown.ToHTML(md)
+			}
+			if err = confirmSubmit(cmd); err != nil {
+				return err
+			}
+
+			var ids []int
+			for _, p := range paths {
+				f, err := os.Open(p)
+				if err != nil {
+					return err
+				}
+				file, err := as.SubmitOsFile(f)
+				f.Close()
+				if err != nil {
+					return fmt.Errorf("could not upload %s: %w", p, err)
+				}
+				ids = append(ids, file.ID)
+			}
+			sub, err := submit.Create(details, typ, ids, body, link)
+			if err != nil {
+				return internal.HandleAuthErr(err)
+			}
+			cmd.Printf("submitted attempt %d (submission %d)\n", sub.Attempt, sub.ID)
+
+			rec := submit.Record{
+				CourseID:     details.CourseID,
+				AssignmentID: details.ID,
+				Assignment:   details.Name,
+				SubmissionID: sub.ID,
+				Attempt:      sub.Attempt,
+				Type:         typ,
+				URL:          link,
+				SubmittedAt:  time.Now(),
+			}
+			if sub.SubmittedAt != nil {
+				rec.SubmittedAt = *sub.SubmittedAt
+			}
+			for _, p := range paths {
+				if abs, err := filepath.Abs(p); err == nil {
+					p = abs
+				}
+				rec.Files = append(rec.Files, p)
+			}
+			return submit.AddHistory(basedir, rec)
+		},
+	}
+	flags := c.Flags()
+	flags.BoolVarP(&text, "text", "t", false, "write a text entry submission in your editor")
+	flags.StringVar(&link, "url", "", "submit a website url")
+	flags.BoolVar(&force, "force", false, "submit even if the assignment checks fail")
+	flags.StringVar(&basedir, "base-dir", basedir, "base directory where submissions are recorded")
+	return c
+}
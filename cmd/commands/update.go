@@ -2,40 +2,108 @@ package commands
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/harrybrwn/config"
 	"github.com/harrybrwn/edu/cmd/internal"
 	"github.com/harrybrwn/edu/cmd/internal/files"
+	"github.com/harrybrwn/edu/cmd/internal/opts"
+	"github.com/harrybrwn/edu/cmd/internal/search"
+	"github.com/harrybrwn/edu/pkg/term"
+	"github.com/harrybrwn/go-canvas"
 	"github.com/spf13/cobra"
 )
 
 type updateCmd struct {
+	*opts.Global
 	all, verbose bool
 	basedir      string
 	testPatters  bool
+	keepVersions bool
+	noProgress   bool
+	migrate      bool
+	prune        bool
+	export       bool
+	submissions  bool
+	noIndex      bool
+	jobs         int
+	pathTemplate string
 	sortBy       []string
 }
 
-func newUpdateCmd() *cobra.Command {
+func newUpdateCmd(globals *opts.Global) *cobra.Command {
 	uc := &updateCmd{
+		Global:  globals,
 		all:     false,
 		verbose: false,
 		sortBy:  []string{"created_at"},
 		basedir: os.ExpandEnv(config.GetString("basedir")),
+		jobs:    config.GetInt("jobs"),
+
+		keepVersions: config.GetBool("keep_versions"),
+		pathTemplate: config.GetString("path_template"),
+		export:       config.GetBool("export_markdown"),
+		submissions:  config.GetBool("download_submissions"),
+		noIndex:      !config.GetBool("search_index"),
 	}
 	cmd := &cobra.Command{
 		Use:   "update",
 		Short: "Download all your files from canvas",
-		RunE:  uc.run,
+		Long: `Download all your files from canvas.
+
+If a course has the files tab hidden, update will look for files in
+the course modules and in the file links of pages and assignments.
+These files are put in folders named after the module they are in.
+
+The download path of each file can be set with a Go template in the
+'path_template' config variable, for example
+
+	{{.Course.CourseCode | lower}}/{{.Folder}}/{{.File.Filename}}
+
+The replacement patterns are applied to the result of the template.
+Use --test-patterns to see where each file will be downloaded.
+
+After changing the replacement patterns or the path template, use
+--migrate to move the files that have already been downloaded to
+their new paths. Migrate prints the moves before doing them and will
+not move anything if a file would be overwritten. Use --migrate with
+--test-patterns to only see the moves.
+
+Files that were downloaded before but have since been deleted or
+unpublished on canvas are listed at the end of an update. Use --prune
+to move them into '<base-dir>/.edu/trash'.
+
+With --markdown, the assignment descriptions, pages, and syllabus of
+each course are written as markdown files next to the course files.
+
+With --submissions, your submitted files, the files attached to
+submission comments, and the files linked in assignment descriptions
+are downloaded into 'submissions/<assignment>' in each course folder.
+
+After downloading, the new and changed files are added to the index
+used by 'edu search' unless --no-index is given.`,
+		RunE: uc.run,
 	}
 	flags := cmd.Flags()
 	flags.BoolVarP(&uc.all, "all", "a", uc.all, "download files from all courses, defaults to only active courses")
 	flags.BoolVarP(&uc.verbose, "verbose", "v", uc.verbose, "run update in verbose mode (prints out files)")
 	flags.BoolVar(&uc.testPatters, "test-patterns", uc.testPatters, "test the replacement patterns from the config file")
 	flags.StringVar(&uc.basedir, "base-dir", uc.basedir, "base directory for file downloads")
+	flags.BoolVar(&uc.keepVersions, "keep-versions", uc.keepVersions, "keep old copies of files that changed on canvas (see 'edu files history')")
 	flags.StringArrayVarP(&uc.sortBy, "sort-by", "s", uc.sortBy, "select the file sorting methods")
+	flags.IntVarP(&uc.jobs, "jobs", "j", uc.jobs, "maximum number of files downloaded at the same time (0 means no limit)")
+	flags.BoolVar(&uc.noProgress, "no-progress", uc.noProgress, "do not show download progress bars")
+	flags.BoolVar(&uc.migrate, "migrate", uc.migrate, "move downloaded files to the paths given by the current replacement patterns")
+	flags.BoolVar(&uc.noIndex, "no-index", uc.noIndex, "do not update the search index")
+	flags.BoolVar(&uc.submissions, "submissions", uc.submissions, "download submissions, feedback attachments, and assignment files")
+	flags.BoolVar(&uc.export, "markdown", uc.export, "export assignments, pages, and the syllabus as markdown")
+	flags.BoolVar(&uc.prune, "prune", uc.prune, "move local files that are no longer on canvas to the trash directory")
+	flags.StringVar(&uc.pathTemplate, "path-template", uc.pathTemplate, "template used for download paths (overrides 'path_template' in the config)")
 	return cmd
 }
 
@@ -45,13 +113,43 @@ func (uc *updateCmd) run(cmd *cobra.Command, args []string) (err error) {
 		return internal.HandleAuthErr(err)
 	}
 	dl := files.NewDownloader(uc.basedir)
+	if uc.pathTemplate != "" {
+		if dl.PathTemplate, err = files.ParseTemplate(uc.pathTemplate); err != nil {
+			return fmt.Errorf("bad path template: %w", err)
+		}
+	}
 	if uc.verbose {
 		dl.Stdout = os.Stdout
 	}
+	dl.KeepVersions = uc.keepVersions
+	dl.Jobs = uc.jobs
+	dl.Errors = internal.Errors
+	dl.Hooks = Conf.Hooks
+	dl.CourseHooks = Conf.CourseHooks
+	if err = setFilters(dl); err != nil {
+		return err
+	}
+	if uc.migrate {
+		return uc.runMigrate(cmd, dl, courses)
+	}
 
 	var fn = dl.Download
 	if uc.testPatters {
 		fn = dl.CheckReplacements
+	} else {
+		dl.Manifest, err = files.OpenManifest(uc.basedir)
+		if err != nil {
+			return fmt.Errorf("could not read file manifest: %w", err)
+		}
+		if !uc.noProgress && term.IsTerminal(os.Stdout) {
+			dl.Progress = term.NewProgress(os.Stdout)
+			if uc.verbose {
+				dl.Stdout = dl.Progress
+			}
+			dl.Progress.Start()
+		}
+		stop := cleanupOnInterrupt(dl)
+		defer stop()
 	}
 	courseReps := upperMapKeys(Conf.CourseReplacements)
 
@@ -59,19 +157,239 @@ func (uc *updateCmd) run(cmd *cobra.Command, args []string) (err error) {
 		if course.AccessRestrictedByDate {
 			continue
 		}
-		reps, ok := courseReps[course.CourseCode]
-		if !ok {
-			reps = Conf.Replacements
-		} else {
-			reps = append(Conf.Replacements, reps...)
+		reps := courseReplacements(courseReps, course)
+		if err = fn(course, reps); err != nil {
+			internal.Errors.Add(course.Name, err)
+			continue
+		}
+		if uc.submissions && dl.Manifest != nil {
+			if err = dl.DownloadSubmissions(course, reps); err != nil {
+				internal.Errors.Add(course.Name, err)
+			}
 		}
-		fn(course, reps)
 	}
 	dl.Wait()
-	fmt.Println("done.")
+	if uc.export && dl.Manifest != nil {
+		// exported after the downloads so that links
+		// can point to the local files
+		for _, course := range courses {
+			if course.AccessRestrictedByDate {
+				continue
+			}
+			if err = dl.Export(course, courseReplacements(courseReps, course)); err != nil {
+				internal.Errors.Add(course.Name, err)
+			}
+		}
+	}
+	if dl.Progress != nil {
+		dl.Progress.Stop()
+	}
+	if dl.Manifest == nil {
+		fmt.Println("done.")
+		return internal.Errors.Finish(cmd.ErrOrStderr())
+	}
+	if err = dl.Manifest.Save(); err != nil {
+		return fmt.Errorf("could not save file manifest: %w", err)
+	}
+	if uc.verbose || dl.Report.Count(files.Failed)+dl.Report.Count(files.HookFailed) > 0 {
+		dl.Report.WriteTo(cmd.OutOrStdout())
+	}
+	uc.printSummary(cmd.OutOrStdout(), dl.Report)
+	if err = uc.orphans(cmd.OutOrStdout(), dl); err != nil {
+		return err
+	}
+	if !uc.noIndex {
+		if err = updateIndex(uc.basedir); err != nil {
+			return fmt.Errorf("could not update search index: %w", err)
+		}
+	}
+	return internal.Errors.Finish(cmd.ErrOrStderr())
+}
+
+// orphans will list or prune the local files
+// that are no longer on canvas.
+func (uc *updateCmd) orphans(w io.Writer, dl *files.CourseDownloader) error {
+	orphans := dl.Orphans()
+	if len(orphans) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "\n%d local files are no longer on canvas:\n", len(orphans))
+	for _, e := range orphans {
+		fmt.Fprintf(w, "  %s\n", e.Path)
+	}
+	if !uc.prune {
+		fmt.Fprintln(w, "use --prune to move them to", filepath.Join(uc.basedir, files.TrashDir))
+		return nil
+	}
+	err := dl.Prune(orphans)
+	if e := dl.Manifest.Save(); e != nil && err == nil {
+		err = fmt.Errorf("could not save file manifest: %w", e)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "moved to", filepath.Join(uc.basedir, files.TrashDir))
+	return nil
+}
+
+func (uc *updateCmd) runMigrate(cmd *cobra.Command, dl *files.CourseDownloader, courses []*canvas.Course) (err error) {
+	dl.Manifest, err = files.OpenManifest(uc.basedir)
+	if err != nil {
+		return fmt.Errorf("could not read file manifest: %w", err)
+	}
+	var (
+		out   = cmd.OutOrStdout()
+		moves []files.Move
+	)
+	courseReps := upperMapKeys(Conf.CourseReplacements)
+	for _, course := range courses {
+		if course.AccessRestrictedByDate {
+			continue
+		}
+		internal.Errors.Attempt(course.Name)
+		reps := courseReplacements(courseReps, course)
+		m, err := dl.Plan(course, reps)
+		if err != nil {
+			internal.Errors.Add(course.Name, err)
+			continue
+		}
+		moves = append(moves, m...)
+	}
+	if err = internal.Errors.Finish(cmd.ErrOrStderr()); err != nil {
+		// a partial plan could leave the files half migrated
+		return err
+	}
+	if len(moves) == 0 {
+		fmt.Fprintln(out, "nothing to migrate")
+		return nil
+	}
+	files.WritePlan(out, moves)
+	if collisions := dl.Collisions(moves); len(collisions) > 0 {
+		fmt.Fprintln(out, "\ncollisions:")
+		for _, c := range collisions {
+			fmt.Fprintln(out, " ", c)
+		}
+		return &internal.Error{Msg: "refusing to migrate files", Code: 1}
+	}
+	if uc.testPatters {
+		return nil
+	}
+	err = dl.Migrate(moves)
+	// save whatever was moved even if there was an error
+	if e := dl.Manifest.Save(); e != nil && err == nil {
+		err = fmt.Errorf("could not save file manifest: %w", e)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "moved %d files\n", dl.Report.Count(files.Moved))
+	return nil
+}
+
+func (uc *updateCmd) printSummary(w io.Writer, report *files.Report) {
+	var (
+		tab     = internal.NewTable(w)
+		header  = []string{"course", "downloaded", "moved", "unchanged", "filtered", "failed"}
+		columns = [][]files.Action{
+			{files.New, files.Updated},
+			{files.Moved},
+			{files.Unchanged},
+			{files.Filtered},
+			{files.Failed},
+		}
+	)
+	if report.Count(files.HookFailed) > 0 {
+		header = append(header, "hooks failed")
+		columns = append(columns, []files.Action{files.HookFailed})
+	}
+	internal.SetTableHeader(tab, header, !uc.NoColor)
+	total := make([]int, len(columns))
+	for _, c := range report.Courses() {
+		row := []string{c.Course}
+		for i, actions := range columns {
+			n := 0
+			for _, a := range actions {
+				n += c.Counts[a]
+			}
+			total[i] += n
+			row = append(row, strconv.Itoa(n))
+		}
+		tab.Append(row)
+	}
+	row := []string{"total"}
+	for _, n := range total {
+		row = append(row, strconv.Itoa(n))
+	}
+	tab.SetFooter(row)
+	tab.Render()
+}
+
+// cleanupOnInterrupt will remove partial downloads and save the
+// manifest if the update is interrupted with Ctrl-C.
+func cleanupOnInterrupt(dl *files.CourseDownloader) (stop func()) {
+	sig := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sig, os.Interrupt)
+	go func() {
+		select {
+		case <-done:
+			return
+		case <-sig:
+		}
+		if dl.Progress != nil {
+			dl.Progress.Stop()
+		}
+		files.CleanPartials()
+		if dl.Manifest != nil {
+			if err := dl.Manifest.Save(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not save file manifest: %v\n", err)
+			}
+		}
+		os.Exit(130)
+	}()
+	return func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
+
+func updateIndex(basedir string) error {
+	ix, err := search.Open(basedir)
+	if err != nil {
+		return err
+	}
+	if _, _, err = ix.Update(); err != nil {
+		return err
+	}
+	return ix.Save()
+}
+
+// setFilters gives the downloader the filters from
+// the config file after checking them.
+func setFilters(dl *files.CourseDownloader) error {
+	if err := Conf.Filters.Validate(); err != nil {
+		return fmt.Errorf("bad filters: %w", err)
+	}
+	for code, f := range Conf.CourseFilters {
+		if err := f.Validate(); err != nil {
+			return fmt.Errorf("bad filters for %s: %w", code, err)
+		}
+	}
+	dl.Filters = Conf.Filters
+	dl.CourseFilters = Conf.CourseFilters
 	return nil
 }
 
+// courseReplacements returns the global replacements followed
+// by the replacements for one course.
+func courseReplacements(courseReps map[string][]files.Replacement, course *canvas.Course) []files.Replacement {
+	reps, ok := courseReps[course.CourseCode]
+	if !ok {
+		return Conf.Replacements
+	}
+	return append(Conf.Replacements[:len(Conf.Replacements):len(Conf.Replacements)], reps...)
+}
+
 func upperMapKeys(m map[string][]files.Replacement) map[string][]files.Replacement {
 	cp := make(map[string][]files.Replacement)
 	for key, val := range m {
@@ -0,0 +1,167 @@
+// Package announce gets course announcements and keeps
+// track of which ones have been read.
+package announce
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/harrybrwn/edu/cmd/internal"
+	"github.com/harrybrwn/edu/cmd/internal/files"
+	"github.com/harrybrwn/edu/cmd/internal/rest"
+	"github.com/harrybrwn/go-canvas"
+)
+
+// Announcement is a course announcement.
+type Announcement struct {
+	ID       int       `json:"id"`
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	UserName string    `json:"user_name"`
+	PostedAt time.Time `json:"posted_at"`
+	HTMLURL  string    `json:"html_url"`
+	Course   string    `json:"-"`
+	CourseID int       `json:"-"`
+}
+
+// Get gets the announcements of a course posted after a date.
+func Get(course *canvas.Course, since time.Time) ([]*Announcement, error) {
+	params := url.Values{
+		"context_codes[]": {fmt.Sprintf("course_%d", course.ID)},
+		"start_date":      {since.UTC().Format(time.RFC3339)},
+		"end_date":        {time.Now().UTC().Add(24 * time.Hour).Format(time.RFC3339)},
+	}
+	var all []*Announcement
+	err := rest.Pages("announcements", params, func(b []byte) error {
+		var page []*Announcement
+		if err := json.Unmarshal(b, &page); err != nil {
+			return err
+		}
+		for _, a := range page {
+			a.Course = course.Name
+			a.CourseID = course.ID
+		}
+		all = append(all, page...)
+		return nil
+	})
+	return all, err
+}
+
+// Fetch gets the announcements for many courses at once. Errors are added
+// to the collector and the announcements are sorted newest first.
+func Fetch(courses []*canvas.Course, since time.Time, errs *internal.Collector) []*Announcement {
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		all []*Announcement
+	)
+	for _, course := range courses {
+		if course.AccessRestrictedByDate {
+			continue
+		}
+		errs.Attempt(course.Name)
+		wg.Add(1)
+		go func(course *canvas.Course) {
+			defer wg.Done()
+			list, err := Get(course, since)
+			if err != nil {
+				errs.Add(course.Name, err)
+				return
+			}
+			mu.Lock()
+			all = append(all, list...)
+			mu.Unlock()
+		}(course)
+	}
+	wg.Wait()
+	Sort(all)
+	return all
+}
+
+// Sort sorts announcements from newest to oldest.
+func Sort(list []*Announcement) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].PostedAt.Equal(list[j].PostedAt) {
+			return list[i].ID > list[j].ID
+		}
+		return list[i].PostedAt.After(list[j].PostedAt)
+	})
+}
+
+// StateFile is the file in the base directory that
+// keeps track of the announcements that have been read.
+var StateFile = filepath.Join(files.MetaDir, "announcements.json")
+
+// State is the set of announcements that have been read.
+type State struct {
+	Read map[int]time.Time `json:"read"`
+	path string
+}
+
+// OpenState reads the read state from the base directory.
+func OpenState(basedir string) (*State, error) {
+	s := &State{
+		Read: make(map[int]time.Time),
+		path: filepath.Join(basedir, StateFile),
+	}
+	b, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	if s.Read == nil {
+		s.Read = make(map[int]time.Time)
+	}
+	return s, nil
+}
+
+// IsRead returns true if the announcement has been read.
+func (s *State) IsRead(a *Announcement) bool {
+	_, ok := s.Read[a.ID]
+	return ok
+}
+
+// MarkRead marks announcements as read.
+func (s *State) MarkRead(list ...*Announcement) {
+	now := time.Now()
+	for _, a := range list {
+		if !s.IsRead(a) {
+			s.Read[a.ID] = now
+		}
+	}
+}
+
+// Unread returns the announcements that have not been read.
+func (s *State) Unread(list []*Announcement) []*Announcement {
+	unread := make([]*Announcement, 0, len(list))
+	for _, a := range list {
+		if !s.IsRead(a) {
+			unread = append(unread, a)
+		}
+	}
+	return unread
+}
+
+// Save writes the read state.
+func (s *State) Save() error {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, b, 0644)
+}
@@ -0,0 +1,53 @@
+package announce
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSort(t *testing.T) {
+	now := time.Now()
+	list := []*Announcement{
+		{ID: 1, PostedAt: now.Add(-time.Hour)},
+		{ID: 2, PostedAt: now},
+		{ID: 3, PostedAt: now.Add(-time.Hour)},
+	}
+	Sort(list)
+	for i, id := range []int{2, 3, 1} {
+		if list[i].ID != id {
+			t.Errorf("expected id %d at %d, got %d", id, i, list[i].ID)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "edu-announce")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s, err := OpenState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := []*Announcement{{ID: 1}, {ID: 2}, {ID: 3}}
+	if n := len(s.Unread(list)); n != 3 {
+		t.Errorf("expected 3 unread, got %d", n)
+	}
+	s.MarkRead(list[0], list[2])
+	if err = s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = OpenState(dir); err != nil {
+		t.Fatal(err)
+	}
+	unread := s.Unread(list)
+	if len(unread) != 1 || unread[0].ID != 2 {
+		t.Errorf("wrong unread announcements: %v", unread)
+	}
+	if !s.IsRead(list[0]) || s.IsRead(list[1]) {
+		t.Error("wrong read state")
+	}
+}
@@ -0,0 +1,345 @@
+// Package archive saves a canvas course as a static html
+// site that can be browsed after the course is closed.
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/harrybrwn/edu/cmd/internal"
+	"github.com/harrybrwn/edu/cmd/internal/files"
+	"github.com/harrybrwn/edu/cmd/internal/rest"
+	"github.com/harrybrwn/go-canvas"
+)
+
+// Archive is a snapshot of a canvas course.
+type Archive struct {
+	Course        *canvas.Course `json:"course"`
+	Syllabus      string         `json:"syllabus"`
+	Modules       []Module       `json:"modules"`
+	Pages         []Page         `json:"pages"`
+	Assignments   []Assignment   `json:"assignments"`
+	Announcements []Topic        `json:"announcements"`
+	Discussions   []Topic        `json:"discussions"`
+	Files         []File         `json:"files"`
+	CreatedAt     time.Time      `json:"created_at"`
+
+	// warn reports the parts of a section that
+	// could not be saved
+	warn func(error) error
+}
+
+// Module is a course module.
+type Module = files.Module
+
+// ModuleItem is one item in a module.
+type ModuleItem = files.ModuleItem
+
+// Page is a course wiki page.
+type Page struct {
+	URL       string    `json:"url"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Assignment is an assignment and the user's submission.
+type Assignment struct {
+	ID              int         `json:"id"`
+	Name            string      `json:"name"`
+	Description     string      `json:"description"`
+	DueAt           time.Time   `json:"due_at"`
+	PointsPossible  float64     `json:"points_possible"`
+	SubmissionTypes []string    `json:"submission_types"`
+	Submission      *Submission `json:"submission,omitempty"`
+}
+
+// Submission is the user's submission for an assignment.
+type Submission struct {
+	Attempt       int          `json:"attempt"`
+	Score         *float64     `json:"score"`
+	Grade         string       `json:"grade"`
+	SubmittedAt   time.Time    `json:"submitted_at"`
+	WorkflowState string       `json:"workflow_state"`
+	Late          bool         `json:"late"`
+	Missing       bool         `json:"missing"`
+	Body          string       `json:"body"`
+	URL           string       `json:"url"`
+	Attachments   []Attachment `json:"attachments"`
+	Comments      []Comment    `json:"submission_comments"`
+}
+
+// Attachment is a file attached to a submission or comment.
+type Attachment struct {
+	ID          int    `json:"id"`
+	Filename    string `json:"filename"`
+	DisplayName string `json:"display_name"`
+	URL         string `json:"url"`
+}
+
+// Comment is a submission comment.
+type Comment struct {
+	Author      string       `json:"author_name"`
+	Comment     string       `json:"comment"`
+	CreatedAt   time.Time    `json:"created_at"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+// Topic is an announcement or a discussion topic.
+type Topic struct {
+	ID       int       `json:"id"`
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	UserName string    `json:"user_name"`
+	PostedAt time.Time `json:"posted_at"`
+	Entries  []Entry   `json:"entries,omitempty"`
+}
+
+// Entry is a reply to a discussion topic.
+type Entry struct {
+	ID        int       `json:"id"`
+	UserName  string    `json:"user_name"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// File is a course file that was saved in the archive.
+type File struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Path is relative to the archive directory.
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// Archiver builds course archives.
+type Archiver struct {
+	// Dir is the directory that the archive is written to.
+	Dir string
+	// NoFiles will skip downloading the course files.
+	NoFiles bool
+	// Jobs is the number of files downloaded at the same time.
+	Jobs   int
+	Stdout io.Writer
+	// Errors collects the errors for parts of the
+	// course that could not be archived.
+	Errors *internal.Collector
+}
+
+// Archive will download everything in a course and
+// write it to the archive directory.
+func (a *Archiver) Archive(course *canvas.Course) (*Archive, error) {
+	if err := os.MkdirAll(a.Dir, 0775); err != nil {
+		return nil, err
+	}
+	arc := &Archive{Course: course, CreatedAt: time.Now(), warn: a.warn(course)}
+	course.SetErrorHandler(arc.warn)
+
+	a.status("fetching course content")
+	steps := []func(*canvas.Course) error{
+		arc.getSyllabus,
+		arc.getModules,
+		arc.getPages,
+		arc.getAssignments,
+		arc.getAnnouncements,
+		arc.getDiscussions,
+	}
+	for _, step := range steps {
+		// parts of a course are often hidden,
+		// save everything that we can
+		a.warn(course)(step(course))
+	}
+	if !a.NoFiles {
+		a.status("downloading files")
+		if err := a.downloadFiles(course, arc); err != nil {
+			return arc, err
+		}
+	}
+	a.status("writing site")
+	if err := arc.writeJSON(filepath.Join(a.Dir, "course.json")); err != nil {
+		return arc, err
+	}
+	return arc, arc.WriteSite(a.Dir)
+}
+
+func (a *Archiver) downloadFiles(course *canvas.Course, arc *Archive) (err error) {
+	dir := filepath.Join(a.Dir, "files")
+	dl := files.NewDownloader(dir)
+	dl.Jobs = a.Jobs
+	dl.Errors = a.Errors
+	if a.Stdout != nil {
+		dl.Stdout = a.Stdout
+	}
+	if dl.PathTemplate, err = files.ParseTemplate("{{.Folder}}/{{.File.Filename}}"); err != nil {
+		return err
+	}
+	if dl.Manifest, err = files.OpenManifest(dir); err != nil {
+		return err
+	}
+	if err = dl.Download(course, nil); err != nil {
+		a.warn(course)(err)
+	} else if err = dl.DownloadSubmissions(course, nil); err != nil {
+		a.warn(course)(err)
+	}
+	dl.Wait()
+	if err = dl.Manifest.Save(); err != nil {
+		return err
+	}
+	for _, e := range dl.Manifest.Entries() {
+		if e.CourseID != course.ID {
+			continue
+		}
+		arc.Files = append(arc.Files, File{
+			ID:   e.ID,
+			Name: filepath.Base(e.Path),
+			Path: filepath.ToSlash(filepath.Join("files", e.Path)),
+			Size: e.Size,
+		})
+	}
+	return nil
+}
+
+func (a *Archiver) warn(course *canvas.Course) func(error) error {
+	return func(err error) error {
+		if err == nil {
+			return nil
+		}
+		if a.Errors != nil {
+			a.Errors.Add(course.Name, err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", course.Name, err)
+		}
+		return err
+	}
+}
+
+func (a *Archiver) status(msg string) {
+	if a.Stdout != nil {
+		fmt.Fprintln(a.Stdout, msg)
+	}
+}
+
+func (arc *Archive) getSyllabus(course *canvas.Course) error {
+	var c struct {
+		Syllabus string `json:"syllabus_body"`
+	}
+	err := rest.Get(rest.Path("courses", course.ID), url.Values{"include[]": {"syllabus_body"}}, &c)
+	arc.Syllabus = c.Syllabus
+	return err
+}
+
+func (arc *Archive) getModules(course *canvas.Course) (err error) {
+	arc.Modules, err = files.CourseModules(course.ID)
+	return err
+}
+
+func (arc *Archive) getPages(course *canvas.Course) error {
+	var list []Page
+	err := rest.Pages(rest.Path("courses", course.ID, "pages"), nil, func(b []byte) error {
+		var page []Page
+		if err := json.Unmarshal(b, &page); err != nil {
+			return err
+		}
+		list = append(list, page...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, p := range list {
+		// the page list does not have the page body
+		if err = rest.Get(rest.Path("courses", course.ID, "pages", p.URL), nil, &p); err != nil {
+			arc.warn(err)
+		}
+		arc.Pages = append(arc.Pages, p)
+	}
+	sort.Slice(arc.Pages, func(i, j int) bool {
+		return strings.ToLower(arc.Pages[i].Title) < strings.ToLower(arc.Pages[j].Title)
+	})
+	return nil
+}
+
+func (arc *Archive) getAssignments(course *canvas.Course) error {
+	err := rest.Pages(rest.Path("courses", course.ID, "assignments"), nil, func(b []byte) error {
+		var page []Assignment
+		if err := json.Unmarshal(b, &page); err != nil {
+			return err
+		}
+		arc.Assignments = append(arc.Assignments, page...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	params := url.Values{"include[]": {"submission_comments"}}
+	for i, as := range arc.Assignments {
+		sub := &Submission{}
+		path := rest.Path("courses", course.ID, "assignments", as.ID, "submissions", "self")
+		if err = rest.Get(path, params, sub); err != nil {
+			arc.warn(err)
+			continue
+		}
+		arc.Assignments[i].Submission = sub
+	}
+	return nil
+}
+
+func (arc *Archive) getAnnouncements(course *canvas.Course) error {
+	params := url.Values{
+		"context_codes[]": {fmt.Sprintf("course_%d", course.ID)},
+		"start_date":      {"2000-01-01"},
+	}
+	return rest.Pages("announcements", params, func(b []byte) error {
+		var page []Topic
+		if err := json.Unmarshal(b, &page); err != nil {
+			return err
+		}
+		arc.Announcements = append(arc.Announcements, page...)
+		return nil
+	})
+}
+
+func (arc *Archive) getDiscussions(course *canvas.Course) error {
+	err := rest.Pages(rest.Path("courses", course.ID, "discussion_topics"), nil, func(b []byte) error {
+		var page []Topic
+		if err := json.Unmarshal(b, &page); err != nil {
+			return err
+		}
+		arc.Discussions = append(arc.Discussions, page...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i, t := range arc.Discussions {
+		path := rest.Path("courses", course.ID, "discussion_topics", t.ID, "entries")
+		err = rest.Pages(path, nil, func(b []byte) error {
+			var page []Entry
+			if err := json.Unmarshal(b, &page); err != nil {
+				return err
+			}
+			arc.Discussions[i].Entries = append(arc.Discussions[i].Entries, page...)
+			return nil
+		})
+		if err != nil {
+			arc.warn(err)
+		}
+	}
+	return nil
+}
+
+func (arc *Archive) writeJSON(filename string) error {
+	b, err := json.MarshalIndent(arc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, b, 0644)
+}
@@ -0,0 +1,70 @@
+package archive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harrybrwn/go-canvas"
+)
+
+func TestWriteSite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "edu-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	score := 9.5
+	arc := &Archive{
+		Course:   &canvas.Course{ID: 12, Name: "CSE 100 01", CourseCode: "CSE 100"},
+		Syllabus: `<p>See <a href="https://canvas.instructure.com/courses/12/pages/week-1">week 1</a></p>`,
+		Modules: []Module{{Name: "Week 1", Items: []ModuleItem{
+			{Title: "Week 1", Type: "Page", PageURL: "week-1"},
+			{Title: "Notes", Type: "File", ContentID: 5},
+			{Title: "Readings", Type: "SubHeader"},
+		}}},
+		Pages: []Page{{URL: "week-1", Title: "Week 1", Body: `<a href="/courses/12/files/5/download">notes</a>`}},
+		Assignments: []Assignment{{
+			ID:             34,
+			Name:           "Homework 1",
+			PointsPossible: 10,
+			DueAt:          time.Now(),
+			Submission:     &Submission{Score: &score, WorkflowState: "graded"},
+		}},
+		Files:     []File{{ID: 5, Name: "notes.pdf", Path: "files/week1/notes.pdf"}},
+		CreatedAt: time.Now(),
+	}
+	if err = arc.WriteSite(dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{
+		"index.html", "modules.html", "pages.html", "assignments.html",
+		"announcements.html", "discussions.html", "files.html",
+		"page-week-1.html", "assignment-34.html",
+	} {
+		if _, err = os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s was not written: %v", name, err)
+		}
+	}
+	expect := map[string][]string{
+		"index.html":         {`href="page-week-1.html"`},
+		"page-week-1.html":   {`href="files/week1/notes.pdf"`},
+		"modules.html":       {`href="page-week-1.html"`, `href="files/week1/notes.pdf"`, "Readings"},
+		"assignments.html":   {`href="assignment-34.html"`, "9.5/10", "graded"},
+		"assignment-34.html": {"Homework 1", "9.5/10"},
+	}
+	for name, parts := range expect {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, part := range parts {
+			if !strings.Contains(string(b), part) {
+				t.Errorf("%s should contain %q", name, part)
+			}
+		}
+	}
+}
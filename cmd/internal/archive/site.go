@@ -0,0 +1,258 @@
+package archive
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/harrybrwn/edu/cmd/internal/files"
+	"github.com/harrybrwn/edu/pkg/term"
+)
+
+const layout = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} - {{.Course.Name}}</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: auto; padding: 1em; line-height: 1.4; }
+nav a { margin-right: 1em; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+.meta { color: #666; }
+.indent-1 { margin-left: 2em; } .indent-2 { margin-left: 4em; } .indent-3 { margin-left: 6em; }
+</style>
+</head>
+<body>
+<nav>
+<a href="index.html">Home</a>
+<a href="modules.html">Modules</a>
+<a href="pages.html">Pages</a>
+<a href="assignments.html">Assignments</a>
+<a href="announcements.html">Announcements</a>
+<a href="discussions.html">Discussions</a>
+<a href="files.html">Files</a>
+</nav>
+<h1>{{.Title}}</h1>
+{{template "content" .}}
+<p class="meta">Archived {{date .Archive.CreatedAt}}</p>
+</body>
+</html>
+`
+
+var pages = map[string]string{
+	"index.html": `<p class="meta">{{.Course.CourseCode}} {{if not .Course.StartAt.IsZero}}{{date .Course.StartAt}} to {{date .Course.EndAt}}{{end}}</p>
+<h2>Syllabus</h2>
+{{body .Archive.Syllabus}}`,
+
+	"modules.html": `{{range .Archive.Modules}}<h2>{{.Name}}</h2>
+<ul>{{range .Items}}<li class="indent-{{.Indent}}">{{with link .}}<a href="{{.}}">{{end}}{{.Title}}{{if link .}}</a>{{end}} <span class="meta">{{.Type}}</span></li>
+{{end}}</ul>
+{{else}}<p>No modules.</p>{{end}}`,
+
+	"pages.html": `<ul>{{range .Archive.Pages}}<li><a href="{{pageFile .URL}}">{{.Title}}</a></li>
+{{else}}<li>No pages.</li>{{end}}</ul>`,
+
+	"assignments.html": `<table>
+<tr><th>Assignment</th><th>Due</th><th>Score</th><th>Status</th></tr>
+{{range .Archive.Assignments}}<tr>
+<td><a href="{{assignmentFile .ID}}">{{.Name}}</a></td>
+<td>{{date .DueAt}}</td>
+<td>{{score .}}</td>
+<td>{{with .Submission}}{{status .}}{{end}}</td>
+</tr>
+{{end}}</table>`,
+
+	"announcements.html": `{{range .Archive.Announcements}}<h2>{{.Title}}</h2>
+<p class="meta">{{.UserName}} {{date .PostedAt}}</p>
+{{body .Message}}
+{{else}}<p>No announcements.</p>{{end}}`,
+
+	"discussions.html": `{{range .Archive.Discussions}}<h2 id="topic-{{.ID}}">{{.Title}}</h2>
+<p class="meta">{{.UserName}} {{date .PostedAt}}</p>
+{{body .Message}}
+{{range .Entries}}<blockquote><p class="meta">{{.UserName}} {{date .CreatedAt}}</p>{{body .Message}}</blockquote>
+{{end}}{{else}}<p>No discussions.</p>{{end}}`,
+
+	"files.html": `<table>
+<tr><th>File</th><th>Size</th></tr>
+{{range .Archive.Files}}<tr><td><a href="{{.Path}}">{{.Path}}</a></td><td>{{size .Size}}</td></tr>
+{{end}}</table>`,
+}
+
+const pageTemplate = `<p class="meta">Updated {{date .Page.UpdatedAt}}</p>
+{{body .Page.Body}}`
+
+const assignmentTemplate = `{{with .Assignment}}<p class="meta">Due {{date .DueAt}} - {{.PointsPossible}} points</p>
+{{body .Description}}
+{{with .Submission}}<h2>Submission</h2>
+<p>Score: {{score $.Assignment}} ({{status .}}){{if not .SubmittedAt.IsZero}}, submitted {{date .SubmittedAt}}{{end}}</p>
+{{if .Body}}{{body .Body}}{{end}}
+{{if .URL}}<p><a href="{{.URL}}">{{.URL}}</a></p>{{end}}
+{{if .Attachments}}<ul>{{range .Attachments}}<li><a href="{{attachment .}}">{{.DisplayName}}</a></li>{{end}}</ul>{{end}}
+{{if .Comments}}<h2>Comments</h2>{{range .Comments}}<blockquote><p class="meta">{{.Author}} {{date .CreatedAt}}</p><p>{{.Comment}}</p>
+{{range .Attachments}}<a href="{{attachment .}}">{{.DisplayName}}</a> {{end}}</blockquote>{{end}}{{end}}
+{{end}}{{end}}`
+
+type pageData struct {
+	*Archive
+	Title      string
+	Page       *Page
+	Assignment *Assignment
+}
+
+// WriteSite writes the archive as a static html site.
+func (arc *Archive) WriteSite(dir string) error {
+	base, err := template.New("layout").Funcs(arc.funcs()).Parse(layout)
+	if err != nil {
+		return err
+	}
+	titles := map[string]string{
+		"index.html":         arc.Course.Name,
+		"modules.html":       "Modules",
+		"pages.html":         "Pages",
+		"assignments.html":   "Assignments",
+		"announcements.html": "Announcements",
+		"discussions.html":   "Discussions",
+		"files.html":         "Files",
+	}
+	for name, content := range pages {
+		err = render(base, content, filepath.Join(dir, name), &pageData{Archive: arc, Title: titles[name]})
+		if err != nil {
+			return err
+		}
+	}
+	for i := range arc.Pages {
+		p := &arc.Pages[i]
+		err = render(base, pageTemplate, filepath.Join(dir, pageFile(p.URL)), &pageData{Archive: arc, Title: p.Title, Page: p})
+		if err != nil {
+			return err
+		}
+	}
+	for i := range arc.Assignments {
+		as := &arc.Assignments[i]
+		err = render(base, assignmentTemplate, filepath.Join(dir, assignmentFile(as.ID)), &pageData{Archive: arc, Title: as.Name, Assignment: as})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func render(base *template.Template, content, filename string, data *pageData) (err error) {
+	t, err := base.Clone()
+	if err != nil {
+		return err
+	}
+	if _, err = t.New("content").Parse(content); err != nil {
+		return err
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := f.Close(); e != nil && err == nil {
+			err = e
+		}
+	}()
+	return t.ExecuteTemplate(f, "layout", data)
+}
+
+func (arc *Archive) funcs() template.FuncMap {
+	local := make(map[int]string)
+	for _, f := range arc.Files {
+		local[f.ID] = f.Path
+	}
+	return template.FuncMap{
+		"body": func(html string) template.HTML {
+			html = files.RewriteFileLinks(html, func(id int) (string, bool) {
+				p, ok := local[id]
+				return p, ok
+			})
+			// canvas html is trusted, it is what the
+			// canvas website shows anyways
+			return template.HTML(rewriteCourseLinks(html))
+		},
+		"link": func(item ModuleItem) string {
+			switch item.Type {
+			case "Page":
+				return pageFile(item.PageURL)
+			case "Assignment":
+				return assignmentFile(item.ContentID)
+			case "Discussion":
+				return fmt.Sprintf("discussions.html#topic-%d", item.ContentID)
+			case "File":
+				return local[item.ContentID]
+			case "ExternalUrl", "ExternalTool":
+				return item.ExternalURL
+			}
+			return ""
+		},
+		"attachment": func(a Attachment) string {
+			if p, ok := local[a.ID]; ok {
+				return p
+			}
+			return a.URL
+		},
+		"score": func(as Assignment) string {
+			if as.Submission == nil || as.Submission.Score == nil {
+				return fmt.Sprintf("-/%g", as.PointsPossible)
+			}
+			return fmt.Sprintf("%g/%g", *as.Submission.Score, as.PointsPossible)
+		},
+		"status": func(s *Submission) string {
+			switch {
+			case s.Missing:
+				return "missing"
+			case s.Late:
+				return "late"
+			}
+			return s.WorkflowState
+		},
+		"date": func(t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.Local().Format("Jan 2, 2006 3:04 PM")
+		},
+		"size":           func(n int64) string { return term.Bytes(n) },
+		"pageFile":       pageFile,
+		"assignmentFile": assignmentFile,
+	}
+}
+
+var unsafeChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+func pageFile(pageURL string) string {
+	return "page-" + unsafeChars.ReplaceAllString(pageURL, "-") + ".html"
+}
+
+func assignmentFile(id int) string {
+	return "assignment-" + strconv.Itoa(id) + ".html"
+}
+
+var courseLinkRegex = regexp.MustCompile(`href="[^"]*/courses/[0-9]+/(pages|assignments|discussion_topics)/([^"/?#]+)[^"]*"`)
+
+// rewriteCourseLinks changes links to course pages, assignments,
+// and discussions so that they point to the archived copies.
+func rewriteCourseLinks(html string) string {
+	return courseLinkRegex.ReplaceAllStringFunc(html, func(attr string) string {
+		m := courseLinkRegex.FindStringSubmatch(attr)
+		switch m[1] {
+		case "pages":
+			return fmt.Sprintf(`href="%s"`, pageFile(m[2]))
+		case "assignments":
+			if id, err := strconv.Atoi(m[2]); err == nil {
+				return fmt.Sprintf(`href="%s"`, assignmentFile(id))
+			}
+		case "discussion_topics":
+			return fmt.Sprintf(`href="discussions.html#topic-%s"`, m[2])
+		}
+		return attr
+	})
+}
@@ -0,0 +1,232 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/harrybrwn/edu/cmd/internal/rest"
+	"github.com/harrybrwn/edu/pkg/throttle"
+	"github.com/harrybrwn/go-canvas"
+)
+
+// Exit codes used when concurrent canvas requests fail.
+const (
+	// ExitFailure means every course failed.
+	ExitFailure = 2
+	// ExitPartialFailure means some courses failed
+	// but the rest finished.
+	ExitPartialFailure = 3
+)
+
+// ErrorKind is a category of canvas error.
+type ErrorKind int
+
+// Error kinds
+const (
+	OtherErr ErrorKind = iota
+	AuthErr
+	RestrictedErr
+	NotFoundErr
+	NetworkErr
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case AuthErr:
+		return "auth"
+	case RestrictedErr:
+		return "restricted"
+	case NotFoundErr:
+		return "not found"
+	case NetworkErr:
+		return "network"
+	}
+	return "other"
+}
+
+// Classify will find the kind of an error.
+func Classify(err error) ErrorKind {
+	var (
+		autherr  *canvas.AuthError
+		resterr  *rest.Error
+		retryerr *throttle.RetryError
+		urlerr   *url.Error
+		neterr   net.Error
+	)
+	switch {
+	case errors.As(err, &retryerr), errors.As(err, &urlerr), errors.As(err, &neterr):
+		return NetworkErr
+	case errors.As(err, &autherr):
+		return AuthErr
+	case errors.As(err, &resterr):
+		switch resterr.Status {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return AuthErr
+		case http.StatusNotFound:
+			return NotFoundErr
+		}
+	}
+	// canvas only gives us messages to work with
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "restricted"):
+		return RestrictedErr
+	case strings.Contains(msg, "not authorized"),
+		strings.Contains(msg, "unauthorized"),
+		strings.Contains(msg, "invalid access token"):
+		return AuthErr
+	case strings.Contains(msg, "not found"),
+		strings.Contains(msg, "does not exist"):
+		return NotFoundErr
+	}
+	return OtherErr
+}
+
+// CourseError is an error that happened while
+// getting data for a course.
+type CourseError struct {
+	Course string
+	Kind   ErrorKind
+	Err    error
+}
+
+func (ce *CourseError) Error() string {
+	if ce.Course == "" {
+		return ce.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", ce.Course, ce.Err)
+}
+
+// Collector gathers the errors from concurrent canvas requests so
+// that a command can finish everything it can and report the
+// failures at the end.
+type Collector struct {
+	mu      sync.Mutex
+	errs    []*CourseError
+	courses map[string]struct{}
+}
+
+// Errors is the collector used for errors that are not
+// tied to any one command.
+var Errors = &Collector{}
+
+// Attempt marks a course as being worked on.
+func (c *Collector) Attempt(course string) {
+	c.mu.Lock()
+	if c.courses == nil {
+		c.courses = make(map[string]struct{})
+	}
+	c.courses[course] = struct{}{}
+	c.mu.Unlock()
+}
+
+// Add will add an error for a course.
+func (c *Collector) Add(course string, err error) {
+	if err == nil {
+		return
+	}
+	c.mu.Lock()
+	c.errs = append(c.errs, &CourseError{Course: course, Kind: Classify(err), Err: err})
+	c.mu.Unlock()
+}
+
+// Handler returns an error handler for a course
+// (see canvas.Course.SetErrorHandler). Only auth errors
+// stop a listing, everything else is collected and the
+// listing goes on.
+func (c *Collector) Handler(course *canvas.Course) func(error) error {
+	c.Attempt(course.Name)
+	return func(err error) error {
+		if err == nil {
+			return nil
+		}
+		c.Add(course.Name, err)
+		if Classify(err) == AuthErr {
+			return err
+		}
+		return nil
+	}
+}
+
+// Len returns the number of errors collected.
+func (c *Collector) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.errs)
+}
+
+// Report writes the errors grouped by kind. Errors that
+// are not tied to a course are written last under their
+// own heading.
+func (c *Collector) Report(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var (
+		general []*CourseError
+		groups  = make(map[ErrorKind][]*CourseError)
+	)
+	for _, e := range c.errs {
+		if e.Course == "" {
+			general = append(general, e)
+			continue
+		}
+		groups[e.Kind] = append(groups[e.Kind], e)
+	}
+	kinds := make([]ErrorKind, 0, len(groups))
+	for k := range groups {
+		kinds = append(kinds, k)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+	for _, k := range kinds {
+		fmt.Fprintf(w, "%s errors (%d):\n", k, len(groups[k]))
+		for _, e := range groups[k] {
+			fmt.Fprintf(w, "  %v\n", e)
+		}
+	}
+	if len(general) > 0 {
+		fmt.Fprintf(w, "general errors (%d):\n", len(general))
+		for _, e := range general {
+			fmt.Fprintf(w, "  %v (%s)\n", e, e.Kind)
+		}
+	}
+}
+
+// Err returns an *Error with an exit code that tells if all
+// the courses failed or only some of them. It returns nil if
+// there were no errors.
+func (c *Collector) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.errs) == 0 {
+		return nil
+	}
+	failed := make(map[string]struct{})
+	for _, e := range c.errs {
+		if e.Course != "" {
+			failed[e.Course] = struct{}{}
+		}
+	}
+	if len(c.courses) > 0 && len(failed) < len(c.courses) {
+		return &Error{
+			Msg:  fmt.Sprintf("%d of %d courses had errors", len(failed), len(c.courses)),
+			Code: ExitPartialFailure,
+		}
+	}
+	return &Error{Msg: "all requests failed", Code: ExitFailure}
+}
+
+// Finish will write the report of a collector and return its error.
+func (c *Collector) Finish(w io.Writer) error {
+	if c.Len() == 0 {
+		return nil
+	}
+	c.Report(w)
+	return c.Err()
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/harrybrwn/go-canvas"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind ErrorKind
+	}{
+		{errors.New("user not authorized to perform that action"), AuthErr},
+		{errors.New("The specified resource does not exist."), NotFoundErr},
+		{errors.New("access to this course has been restricted"), RestrictedErr},
+		{errors.New("something else"), OtherErr},
+	}
+	for _, tst := range tests {
+		if k := Classify(tst.err); k != tst.kind {
+			t.Errorf("wrong kind for %q: got %v; want %v", tst.err, k, tst.kind)
+		}
+	}
+}
+
+func TestCollectorErr(t *testing.T) {
+	c := &Collector{}
+	c.Attempt("a")
+	c.Attempt("b")
+	if c.Err() != nil {
+		t.Error("no errors should give a nil error")
+	}
+	c.Add("a", errors.New("not found"))
+	e, ok := c.Err().(*Error)
+	if !ok || e.Code != ExitPartialFailure {
+		t.Errorf("expected a partial failure, got %v", c.Err())
+	}
+	c.Add("b", errors.New("not found"))
+	e, ok = c.Err().(*Error)
+	if !ok || e.Code != ExitFailure {
+		t.Errorf("expected a total failure, got %v", c.Err())
+	}
+}
+
+func TestCollectorHandler(t *testing.T) {
+	c := &Collector{}
+	handle := c.Handler(&canvas.Course{Name: "a"})
+	if handle(errors.New("not found")) != nil {
+		t.Error("the listing should go on after a non-auth error")
+	}
+	if handle(errors.New("user not authorized to perform that action")) == nil {
+		t.Error("auth errors should stop the listing")
+	}
+	c.Add("", errors.New("timeout"))
+	var buf bytes.Buffer
+	c.Report(&buf)
+	exp := "auth errors (1):\n  a: user not authorized to perform that action\n" +
+		"not found errors (1):\n  a: not found\n" +
+		"general errors (1):\n  timeout (other)\n"
+	if buf.String() != exp {
+		t.Errorf("wrong report:\n%s", buf.String())
+	}
+}
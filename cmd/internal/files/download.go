@@ -9,45 +9,57 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"text/template"
 
+	"github.com/harrybrwn/edu/cmd/internal"
+	"github.com/harrybrwn/edu/pkg/term"
 	"github.com/harrybrwn/go-canvas"
-	"github.com/pkg/errors"
 )
 
 // Download will download a canvas file and write it to
 // a file named by filename if it does not already exist.
 func Download(
-	file io.WriterTo,
+	file *canvas.File,
 	filename string,
 	stdout, stderr io.Writer,
-) (err error) {
-	osfile, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
-	if os.IsExist(err) {
+) error {
+	if exists(filename) {
 		fmt.Fprintf(stdout, "file exists %s\n", filename)
 		return nil
 	}
-	if err != nil {
-		return errors.Wrap(err, "file error")
+	if err := fetch(file, filename, stdout, nil); err != nil {
+		return err
 	}
+	printDownloaded(stdout, filename)
+	return nil
+}
+
+// fetch downloads a file into a partial file and then moves
+// it to filename, replacing any existing file. The bar is
+// optional and used to track the download progress.
+func fetch(file *canvas.File, filename string, stdout io.Writer, bar *term.Bar) (err error) {
 	defer func() {
-		if e := osfile.Close(); e != nil && err == nil {
-			err = e
-		}
 		if err != nil {
 			log.Printf("Error: %s", err.Error())
 		}
-		// io.Discard is usually set when verbose output is turned off
-		// we want to output the "Downloaded" statement anyways
-		if stdout == ioutil.Discard {
-			fmt.Printf("Downloaded %s\n", filename)
-		} else {
-			fmt.Fprintf(stdout, "Downloaded %s\n", filename)
-		}
-		log.Printf("Downloaded %s\n", filename)
 	}()
 	fmt.Fprintf(stdout, "Fetching %s\n", filename)
-	_, err = file.WriteTo(osfile) // download the contents to the file
-	return err
+	part := filename + partialExt
+	if err = writePartial(file, part, bar); err != nil {
+		return err
+	}
+	return os.Rename(part, filename)
+}
+
+func printDownloaded(stdout io.Writer, filename string) {
+	// io.Discard is usually set when verbose output is turned off
+	// we want to output the "Downloaded" statement anyways
+	if stdout == ioutil.Discard {
+		fmt.Printf("Downloaded %s\n", filename)
+	} else {
+		fmt.Fprintf(stdout, "Downloaded %s\n", filename)
+	}
+	log.Printf("Downloaded %s\n", filename)
 }
 
 // NewDownloader creates a new CourseDownloader
@@ -55,8 +67,13 @@ func NewDownloader(basedir string) *CourseDownloader {
 	return &CourseDownloader{
 		Stdout:  ioutil.Discard,
 		Stderr:  os.Stderr,
+		Report:  new(Report),
 		wg:      new(sync.WaitGroup),
 		basedir: basedir,
+		listing: listing{
+			files:   make(map[int]struct{}),
+			courses: make(map[int]bool),
+		},
 	}
 }
 
@@ -65,8 +82,13 @@ func DownloaderFromWG(basedir string, wg *sync.WaitGroup) *CourseDownloader {
 	return &CourseDownloader{
 		Stdout:  os.Stdout,
 		Stderr:  os.Stderr,
+		Report:  new(Report),
 		wg:      wg,
 		basedir: basedir,
+		listing: listing{
+			files:   make(map[int]struct{}),
+			courses: make(map[int]bool),
+		},
 	}
 }
 
@@ -74,8 +96,45 @@ func DownloaderFromWG(basedir string, wg *sync.WaitGroup) *CourseDownloader {
 // canvas course.
 type CourseDownloader struct {
 	Stdout, Stderr io.Writer
-	wg             *sync.WaitGroup
-	basedir        string
+	// Manifest is used to decide which files need to be
+	// downloaded. If nil, only files that do not exist
+	// locally are downloaded.
+	Manifest *Manifest
+	// Report holds the result of every file handled.
+	Report *Report
+	// KeepVersions will save the old copy of a file that
+	// changed on canvas instead of overwriting it.
+	KeepVersions bool
+	// Jobs is the maximum number of files downloaded at
+	// the same time. Zero means no limit.
+	Jobs int
+	// Progress will show progress bars for each download
+	// if it is not nil.
+	Progress *term.Progress
+	// PathTemplate is used to find the path of a file
+	// if it is not nil (see ParseTemplate).
+	PathTemplate *template.Template
+	// Hooks are run after a file is downloaded or
+	// updated and CourseHooks are only run for the
+	// course with a matching course code.
+	Hooks       []Hook
+	CourseHooks map[string][]Hook
+	// Filters decide which files are downloaded and
+	// CourseFilters are added to them for the course
+	// with a matching course code.
+	Filters       Filters
+	CourseFilters map[string]Filters
+	// Errors collects the errors for each course. If nil,
+	// errors are printed as warnings.
+	Errors *internal.Collector
+
+	wg      *sync.WaitGroup
+	sem     chan struct{}
+	once    sync.Once
+	basedir string
+
+	mu      sync.Mutex
+	listing listing
 }
 
 // Wait calls wait on the internal waitgroup
@@ -86,15 +145,77 @@ func (cd *CourseDownloader) Wait() {
 // Download will download all the files for a course and perform the
 // replacement patterns.
 func (cd *CourseDownloader) Download(course *canvas.Course, replacements []Replacement) error {
-	pairs := cd.filesGenerator(course)
+	cd.listCourse(course.ID)
+	cd.downloadPairs(course, cd.term(course), cd.filesGenerator(course), replacements)
+	return nil
+}
+
+// downloadPairs starts a download for every file sent on pairs. A file
+// that fails does not stop the others so that the generator is always
+// drained and the rest of the listing is still seen.
+func (cd *CourseDownloader) downloadPairs(course *canvas.Course, courseTerm Term, pairs <-chan *filePathPair, reps []Replacement) {
 	for pair := range pairs {
+		if pair.file != nil {
+			cd.see(pair.file.ID)
+		}
 		if pair.err != nil {
-			return pair.err
+			cd.fail(course, pair.path, pair.err)
+			continue
+		}
+		if pair.skip != "" {
+			fmt.Fprintf(cd.Stdout, "Skipping %s (%s)\n", cd.rel(pair.path), pair.skip)
+			cd.Report.add(Record{Action: Filtered, Course: course.Name, Path: cd.rel(pair.path)})
+			continue
+		}
+		path, err := cd.destination(course, courseTerm, pair)
+		if err != nil {
+			cd.fail(course, pair.path, err)
+			continue
 		}
+		cd.acquire()
 		cd.wg.Add(1)
-		go cd.downloadFile(pair.file, pair.path, replacements)
+		go cd.downloadFile(course, pair, path, reps)
+	}
+}
+
+// fail records a file that could not be handled. The course's
+// listing is marked as failed so that nothing is pruned from it.
+func (cd *CourseDownloader) fail(course *canvas.Course, path string, err error) {
+	cd.listFailed(course.ID)
+	cd.Report.add(Record{Action: Failed, Course: course.Name, Path: cd.rel(path), Err: err})
+	if cd.Errors != nil {
+		cd.Errors.Add(course.Name, err)
+	} else {
+		fmt.Fprintf(cd.Stderr, "Warning: %s: %v\n", course.Name, err)
+	}
+}
+
+// acquire blocks until there are less than cd.Jobs
+// downloads running.
+func (cd *CourseDownloader) acquire() {
+	cd.once.Do(func() {
+		if cd.Jobs > 0 {
+			cd.sem = make(chan struct{}, cd.Jobs)
+		}
+	})
+	if cd.sem != nil {
+		cd.sem <- struct{}{}
+	}
+}
+
+func (cd *CourseDownloader) release() {
+	if cd.sem != nil {
+		<-cd.sem
 	}
-	return nil
+}
+
+// status is where messages about finished
+// downloads are written.
+func (cd *CourseDownloader) status() io.Writer {
+	if cd.Progress != nil {
+		return cd.Progress
+	}
+	return cd.Stdout
 }
 
 // CheckReplacements will print the result of replacement patterns
@@ -103,12 +224,22 @@ func (cd *CourseDownloader) CheckReplacements(
 	course *canvas.Course,
 	reps []Replacement,
 ) (err error) {
+	courseTerm := cd.term(course)
 	pairs := cd.filesGenerator(course)
+	defer drain(pairs)
 	for pair := range pairs {
 		if pair.err != nil {
 			return pair.err
 		}
-		result, err := DoReplacements(reps, pair.path)
+		if pair.skip != "" {
+			fmt.Printf("%s => skipped by rule %s\n", cd.rel(pair.path), pair.skip)
+			continue
+		}
+		path, err := cd.destination(course, courseTerm, pair)
+		if err != nil {
+			return err
+		}
+		result, err := DoReplacements(reps, path)
 		if err != nil {
 			return err
 		}
@@ -124,15 +255,37 @@ func (cd *CourseDownloader) CheckReplacements(
 		if spaces < 0 {
 			spaces = 0
 		}
+		if cd.PathTemplate != nil {
+			relPath, err := filepath.Rel(cd.basedir, path)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s %s=> %s => %s\n", relFullpath, strings.Repeat(" ", spaces), relPath, relResult)
+			continue
+		}
 		fmt.Printf("%s %s=> %s\n", relFullpath, strings.Repeat(" ", spaces), relResult)
 	}
 	return nil
 }
 
+// drain reads the rest of a generator's files so
+// that its goroutine is not left blocked on a send.
+func drain(pairs <-chan *filePathPair) {
+	for range pairs {
+	}
+}
+
 type filePathPair struct {
-	path string
-	file *canvas.File
-	err  error
+	path   string // default path
+	folder string
+	file   *canvas.File
+	err    error
+	// skip is the filter rule that the file
+	// is skipped by
+	skip string
+	// source is where the file was found if it
+	// is not in the course files (see Entry.Source)
+	source string
 }
 
 func (cd *CourseDownloader) filesGenerator(course *canvas.Course) <-chan *filePathPair {
@@ -144,11 +297,19 @@ func (cd *CourseDownloader) filesGenerator(course *canvas.Course) <-chan *filePa
 	)
 	go func() {
 		defer close(ch)
+		var (
+			denied  error
+			handler = cd.errorHandler(course)
+			filters = cd.filters(course)
+		)
 		course.SetErrorHandler(func(e error) error {
-			if e != nil {
-				fmt.Fprintf(os.Stderr, "Warning: not authorized to get files for \"%s\"\n", course.Name)
+			if e != nil && internal.Classify(e) == internal.AuthErr {
+				// the files tab may be hidden, this is
+				// checked once the listing is done
+				denied = e
+				return e
 			}
-			return e
+			return handler(e)
 		})
 		// perm, err := course.Permissions()
 		// if err != nil {
@@ -171,25 +332,214 @@ func (cd *CourseDownloader) filesGenerator(course *canvas.Course) <-chan *filePa
 				pair.err = err
 				goto SendFile
 			}
+			pair.folder = rel
 			pair.path = filepath.Join(cd.basedir, course.Name, rel, file.Filename)
+			pair.skip = filters.Skip(file, rel)
 		SendFile:
 			ch <- pair
 		}
+		course.SetErrorHandler(handler)
+		if denied == nil {
+			return
+		}
+		// only the files tab is hidden if the modules can still be
+		// read, otherwise the token itself is not allowed in
+		modules, err := CourseModules(course.ID)
+		if err != nil {
+			handler(denied)
+			return
+		}
+		cd.crawlModules(course, modules, filters, ch)
 	}()
 	return ch
 }
 
-func (cd *CourseDownloader) downloadFile(file *canvas.File, path string, reps []Replacement) error {
-	defer cd.wg.Done()
+// errorHandler returns the error handler used for a course.
+func (cd *CourseDownloader) errorHandler(course *canvas.Course) func(error) error {
+	var handle func(error) error
+	if cd.Errors != nil {
+		handle = cd.Errors.Handler(course)
+	} else {
+		handle = func(e error) error {
+			if e != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", course.Name, e)
+			}
+			return e
+		}
+	}
+	return func(e error) error {
+		if e != nil {
+			// the file listing is incomplete
+			cd.listFailed(course.ID)
+		}
+		return handle(e)
+	}
+}
+
+func (cd *CourseDownloader) downloadFile(course *canvas.Course, pair *filePathPair, path string, reps []Replacement) (err error) {
+	file := pair.file
+	defer func() {
+		cd.release()
+		cd.wg.Done()
+	}()
 	fullpath, err := DoReplacements(reps, path)
 	if err != nil {
+		cd.Report.add(Record{Action: Failed, Course: course.Name, Path: cd.rel(path), Err: err})
 		return err
 	}
-	dir := filepath.Dir(fullpath)
-	if err := mkdir(dir); err != nil {
+	defer func() {
+		if err != nil {
+			cd.Report.add(Record{Action: Failed, Course: course.Name, Path: cd.rel(fullpath), Err: err})
+			if cd.Errors != nil {
+				cd.Errors.Add(course.Name, err)
+			}
+		}
+	}()
+	if cd.Manifest == nil {
+		dir := filepath.Dir(fullpath)
+		if err := mkdir(dir); err != nil {
+			return err
+		}
+		return Download(file, fullpath, cd.Stdout, cd.Stderr)
+	}
+	if err = cd.syncFile(course, file, fullpath); err != nil {
 		return err
 	}
-	return Download(file, fullpath, cd.Stdout, cd.Stderr)
+	if pair.source != "" {
+		cd.Manifest.setSource(file.ID, pair.source)
+	}
+	return nil
+}
+
+// syncFile uses the manifest to decide if a file should be
+// downloaded, moved, or left alone.
+func (cd *CourseDownloader) syncFile(course *canvas.Course, file *canvas.File, fullpath string) error {
+	var (
+		rel    = cd.rel(fullpath)
+		entry  = cd.Manifest.Get(file.ID)
+		action = New
+	)
+	switch {
+	case entry == nil:
+		// The file may have been downloaded before
+		// there was a manifest.
+		if stat, err := os.Stat(fullpath); err == nil {
+			if stat.Size() == int64(file.Size) {
+				return cd.track(course, file, fullpath, Unchanged)
+			}
+			action = Updated
+		}
+	case !upToDate(entry, file):
+		action = Updated
+	case entry.Path != rel:
+		return cd.move(course, entry, file, fullpath)
+	default:
+		if exists(fullpath) {
+			cd.Report.add(Record{Action: Unchanged, Course: course.Name, Path: rel})
+			return nil
+		}
+	}
+
+	if action == Updated {
+		if entry == nil {
+			entry = &Entry{ID: file.ID, CourseID: course.ID, Path: rel}
+		}
+		if cd.KeepVersions {
+			if err := cd.Manifest.StashVersion(entry); err != nil {
+				return err
+			}
+		}
+		old := cd.Manifest.Fullpath(entry)
+		if old != fullpath {
+			if err := os.Remove(old); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	if err := mkdir(filepath.Dir(fullpath)); err != nil {
+		return err
+	}
+	var bar *term.Bar
+	if cd.Progress != nil {
+		bar = cd.Progress.NewBar(file.Filename, int64(file.Size))
+		defer cd.Progress.Remove(bar)
+	}
+	// the new copy replaces the old one once the download is complete
+	if err := fetch(file, fullpath, cd.Stdout, bar); err != nil {
+		return err
+	}
+	printDownloaded(cd.status(), fullpath)
+	if err := cd.track(course, file, fullpath, action); err != nil {
+		return err
+	}
+	cd.runHooks(course, file, fullpath, action)
+	return nil
+}
+
+// move will move a file that has not changed on canvas but
+// has a new destination path.
+func (cd *CourseDownloader) move(course *canvas.Course, entry *Entry, file *canvas.File, fullpath string) error {
+	oldpath := cd.Manifest.Fullpath(entry)
+	if !exists(oldpath) {
+		// nothing to move so download it to the new location
+		entry.Path = cd.rel(fullpath)
+		cd.Manifest.Set(entry)
+		return cd.syncFile(course, file, fullpath)
+	}
+	if exists(fullpath) {
+		return fmt.Errorf("cannot move %s: destination already exists", entry.Path)
+	}
+	if err := mkdir(filepath.Dir(fullpath)); err != nil {
+		return err
+	}
+	if err := os.Rename(oldpath, fullpath); err != nil {
+		return err
+	}
+	from := entry.Path
+	entry.Path = cd.rel(fullpath)
+	cd.Manifest.Set(entry)
+	cd.Report.add(Record{Action: Moved, Course: course.Name, Path: entry.Path, From: from})
+	return nil
+}
+
+// track adds a local file to the manifest.
+func (cd *CourseDownloader) track(course *canvas.Course, file *canvas.File, fullpath string, action Action) error {
+	sum, err := checksum(fullpath)
+	if err != nil {
+		return err
+	}
+	entry := &Entry{
+		ID:        file.ID,
+		CourseID:  course.ID,
+		Path:      cd.rel(fullpath),
+		Size:      int64(file.Size),
+		UpdatedAt: file.UpdatedAt,
+		Checksum:  sum,
+	}
+	if prev := cd.Manifest.Get(file.ID); prev != nil {
+		entry.Versions = prev.Versions
+		entry.Source = prev.Source
+	}
+	cd.Manifest.Set(entry)
+	cd.Report.add(Record{Action: action, Course: course.Name, Path: entry.Path})
+	return nil
+}
+
+func (cd *CourseDownloader) rel(p string) string {
+	rel, err := filepath.Rel(cd.basedir, p)
+	if err != nil {
+		return p
+	}
+	return rel
+}
+
+func upToDate(e *Entry, file *canvas.File) bool {
+	return e.Size == int64(file.Size) && e.UpdatedAt.Equal(file.UpdatedAt)
+}
+
+func exists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
 }
 
 func relpath(base, p string) string {
@@ -0,0 +1,176 @@
+package files
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/harrybrwn/edu/cmd/internal/rest"
+	"github.com/harrybrwn/go-canvas"
+	"github.com/jaytaylor/html2text"
+)
+
+// Export writes the assignment descriptions, pages, and syllabus of a
+// course as markdown files next to the course's downloaded files. Links
+// to canvas files that have been downloaded are changed to point to the
+// local copies. Export should be called after the course files have been
+// downloaded.
+func (cd *CourseDownloader) Export(course *canvas.Course, reps []Replacement) error {
+	var (
+		courseTerm = cd.term(course)
+		warn       = cd.warnHandler(course)
+		used       = make(map[string]bool)
+	)
+	course.SetErrorHandler(warn)
+	defer course.SetErrorHandler(cd.errorHandler(course))
+	write := func(folder string, id int, name, title, header, html string) error {
+		filename := cleanName(name)
+		// different titles can have the same cleaned name
+		if key := filepath.Join(folder, strings.ToLower(filename)); used[key] {
+			filename = fmt.Sprintf("%s-%d", filename, id)
+		}
+		used[filepath.Join(folder, strings.ToLower(filename))] = true
+		filename += ".md"
+		path, err := cd.destination(course, courseTerm, &filePathPair{
+			path:   filepath.Join(cd.basedir, course.Name, folder, filename),
+			folder: folder,
+			file:   &canvas.File{Filename: filename, DisplayName: name, ContentType: "text/markdown"},
+		})
+		if err != nil {
+			return err
+		}
+		if path, err = DoReplacements(reps, path); err != nil {
+			return err
+		}
+		return cd.writeMarkdown(path, title, header, html)
+	}
+
+	var syllabus struct {
+		Body string `json:"syllabus_body"`
+	}
+	err := rest.Get(rest.Path("courses", course.ID), url.Values{"include[]": {"syllabus_body"}}, &syllabus)
+	if err != nil {
+		warn(err)
+	} else if strings.TrimSpace(syllabus.Body) != "" {
+		if err = write("", course.ID, "syllabus", course.Name+" Syllabus", "", syllabus.Body); err != nil {
+			return err
+		}
+	}
+
+	for as := range course.Assignments() {
+		header := ""
+		if !as.DueAt.IsZero() {
+			header = fmt.Sprintf("Due: %s\n", as.DueAt.Local().Format("Mon Jan 2, 2006 3:04 PM"))
+		}
+		if err = write("assignments", as.ID, as.Name, as.Name, header, as.Description); err != nil {
+			return err
+		}
+	}
+
+	err = rest.Pages(rest.Path("courses", course.ID, "pages"), nil, func(b []byte) error {
+		var pages []page
+		if err := json.Unmarshal(b, &pages); err != nil {
+			return err
+		}
+		for _, p := range pages {
+			// the page list does not include the body
+			if err := rest.Get(rest.Path("courses", course.ID, "pages", p.URL), nil, &p); err != nil {
+				warn(err)
+				continue
+			}
+			if err := write("pages", p.ID, p.Title, p.Title, "", p.Body); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		warn(err)
+	}
+	return nil
+}
+
+// writeMarkdown converts html to markdown and writes it to a
+// file if the file does not already have the same content.
+func (cd *CourseDownloader) writeMarkdown(path, title, header, html string) error {
+	html = RewriteFileLinks(html, func(id int) (string, bool) {
+		if cd.Manifest == nil {
+			return "", false
+		}
+		entry := cd.Manifest.Get(id)
+		if entry == nil {
+			return "", false
+		}
+		rel, err := filepath.Rel(filepath.Dir(path), cd.Manifest.Fullpath(entry))
+		if err != nil {
+			return "", false
+		}
+		return filepath.ToSlash(rel), true
+	})
+	text, err := html2text.FromString(html, html2text.Options{PrettyTables: true})
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s\n\n", title)
+	if header != "" {
+		fmt.Fprintf(&buf, "%s\n", header)
+	}
+	buf.WriteString(text)
+	buf.WriteByte('\n')
+
+	if old, err := ioutil.ReadFile(path); err == nil && bytes.Equal(old, buf.Bytes()) {
+		return nil
+	}
+	if err = mkdir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(cd.status(), "Exported %s\n", cd.rel(path))
+	return nil
+}
+
+// warnHandler returns an error handler that reports errors
+// without marking the course's file listing as incomplete.
+func (cd *CourseDownloader) warnHandler(course *canvas.Course) func(error) error {
+	return func(e error) error {
+		if e == nil {
+			return nil
+		}
+		if cd.Errors != nil {
+			cd.Errors.Add(course.Name, e)
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", course.Name, e)
+		}
+		return e
+	}
+}
+
+var linkAttrRegex = regexp.MustCompile(`(href|src)="([^"]*/files/([0-9]+)[^"]*)"`)
+
+// RewriteFileLinks replaces the links to canvas files in some
+// html with the path given by local. Links are left alone when
+// local returns false.
+func RewriteFileLinks(html string, local func(id int) (string, bool)) string {
+	return linkAttrRegex.ReplaceAllStringFunc(html, func(attr string) string {
+		m := linkAttrRegex.FindStringSubmatch(attr)
+		id, err := strconv.Atoi(m[3])
+		if err != nil {
+			return attr
+		}
+		p, ok := local(id)
+		if !ok {
+			return attr
+		}
+		return fmt.Sprintf(`%s="%s"`, m[1], p)
+	})
+}
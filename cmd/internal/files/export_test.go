@@ -0,0 +1,19 @@
+package files
+
+import "testing"
+
+func TestRewriteFileLinks(t *testing.T) {
+	html := `<a href="https://canvas.instructure.com/courses/12/files/345/download?wrap=1">notes</a>` +
+		`<img src="/courses/12/files/678/preview"><a href="/courses/12/pages/home">home</a>`
+	result := RewriteFileLinks(html, func(id int) (string, bool) {
+		if id == 345 {
+			return "../lectures/notes.pdf", true
+		}
+		return "", false
+	})
+	exp := `<a href="../lectures/notes.pdf">notes</a>` +
+		`<img src="/courses/12/files/678/preview"><a href="/courses/12/pages/home">home</a>`
+	if result != exp {
+		t.Errorf("wrong result:\n got %s\nwant %s", result, exp)
+	}
+}
@@ -0,0 +1,221 @@
+package files
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/harrybrwn/go-canvas"
+)
+
+// Filters decide which files are downloaded. A file is skipped if it
+// is bigger than MaxSize, if it matches any of the Exclude rules, or
+// if there are Include rules and it does not match any of them.
+type Filters struct {
+	// MaxSize is the largest file that will be
+	// downloaded (ex. "500MB"). Empty means no limit.
+	MaxSize string `yaml:"max_size"`
+	Include []Rule `yaml:"include"`
+	Exclude []Rule `yaml:"exclude"`
+}
+
+// Rule matches files by name, content type, or folder. A file
+// matches a rule if it matches every field that is set.
+type Rule struct {
+	Name string `yaml:"name"`
+	// Glob is a shell pattern for the file name (ex. "*.mp4").
+	Glob string `yaml:"glob"`
+	// Regex is a regular expression for the file name.
+	Regex string `yaml:"regex"`
+	// ContentTypes are MIME types that may end
+	// in a wildcard (ex. "video/*").
+	ContentTypes []string `yaml:"content_types"`
+	// Folders are the names of canvas folders or
+	// modules, they can be shell patterns.
+	Folders []string `yaml:"folders"`
+}
+
+func (r *Rule) String() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	var parts []string
+	if r.Glob != "" {
+		parts = append(parts, "glob "+strconv.Quote(r.Glob))
+	}
+	if r.Regex != "" {
+		parts = append(parts, "regex "+strconv.Quote(r.Regex))
+	}
+	if len(r.ContentTypes) > 0 {
+		parts = append(parts, "content types "+strings.Join(r.ContentTypes, ","))
+	}
+	if len(r.Folders) > 0 {
+		parts = append(parts, "folders "+strings.Join(r.Folders, ","))
+	}
+	return strings.Join(parts, " and ")
+}
+
+func (r *Rule) validate() error {
+	if r.Glob != "" {
+		if _, err := filepath.Match(r.Glob, ""); err != nil {
+			return fmt.Errorf("bad glob %q: %w", r.Glob, err)
+		}
+	}
+	if r.Regex != "" {
+		if _, err := regexp.Compile(r.Regex); err != nil {
+			return err
+		}
+	}
+	for _, pat := range append(r.ContentTypes, r.Folders...) {
+		if _, err := path.Match(pat, ""); err != nil {
+			return fmt.Errorf("bad pattern %q: %w", pat, err)
+		}
+	}
+	return nil
+}
+
+// Match returns true if the file matches the rule.
+func (r *Rule) Match(file *canvas.File, folder string) bool {
+	if r.Glob != "" {
+		if ok, _ := filepath.Match(r.Glob, file.Filename); !ok {
+			return false
+		}
+	}
+	if r.Regex != "" {
+		if ok, _ := regexp.MatchString(r.Regex, file.Filename); !ok {
+			return false
+		}
+	}
+	if len(r.ContentTypes) > 0 && !matchAny(r.ContentTypes, file.ContentType) {
+		return false
+	}
+	if len(r.Folders) > 0 && !matchFolder(r.Folders, folder) {
+		return false
+	}
+	return true
+}
+
+// Validate checks that all the rules and the max size can be used.
+func (f *Filters) Validate() error {
+	if _, err := ParseSize(f.MaxSize); err != nil {
+		return err
+	}
+	for _, rules := range [][]Rule{f.Include, f.Exclude} {
+		for i := range rules {
+			if err := rules[i].validate(); err != nil {
+				return fmt.Errorf("filter %s: %w", rules[i].String(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// Skip returns the name of the rule that a file is skipped by,
+// or an empty string if the file should be downloaded.
+func (f *Filters) Skip(file *canvas.File, folder string) string {
+	if max, err := ParseSize(f.MaxSize); err == nil && max > 0 && int64(file.Size) > max {
+		return "max_size " + f.MaxSize
+	}
+	for i := range f.Exclude {
+		if f.Exclude[i].Match(file, folder) {
+			return "exclude " + f.Exclude[i].String()
+		}
+	}
+	if len(f.Include) == 0 {
+		return ""
+	}
+	for i := range f.Include {
+		if f.Include[i].Match(file, folder) {
+			return ""
+		}
+	}
+	return "include (no rule matched)"
+}
+
+// merge returns the filters for one course given
+// the global filters.
+func (f Filters) merge(course Filters) Filters {
+	if course.MaxSize != "" {
+		f.MaxSize = course.MaxSize
+	}
+	f.Include = append(f.Include[:len(f.Include):len(f.Include)], course.Include...)
+	f.Exclude = append(f.Exclude[:len(f.Exclude):len(f.Exclude)], course.Exclude...)
+	return f
+}
+
+// filters returns the global filters merged with the
+// filters for a course.
+func (cd *CourseDownloader) filters(course *canvas.Course) Filters {
+	f := cd.Filters
+	for code, cf := range cd.CourseFilters {
+		if strings.EqualFold(code, course.CourseCode) {
+			f = f.merge(cf)
+		}
+	}
+	return f
+}
+
+var sizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"K":   1 << 10,
+	"KB":  1 << 10,
+	"KIB": 1 << 10,
+	"M":   1 << 20,
+	"MB":  1 << 20,
+	"MIB": 1 << 20,
+	"G":   1 << 30,
+	"GB":  1 << 30,
+	"GIB": 1 << 30,
+}
+
+// ParseSize parses a size like "500MB" or "1.5G" into a number
+// of bytes. Units are powers of 1024. Empty is zero.
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad size %q", s)
+	}
+	unit, ok := sizeUnits[strings.ToUpper(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("bad size unit in %q", s)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, pat := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pat), strings.ToLower(s)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFolder checks the whole folder path and
+// each folder in it against the patterns.
+func matchFolder(patterns []string, folder string) bool {
+	folder = filepath.ToSlash(folder)
+	if matchAny(patterns, folder) {
+		return true
+	}
+	for _, name := range strings.Split(folder, "/") {
+		if matchAny(patterns, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/harrybrwn/go-canvas"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in  string
+		exp int64
+	}{
+		{"", 0},
+		{"100", 100},
+		{"1KB", 1024},
+		{"1.5 MB", 3 << 19},
+		{"2GiB", 2 << 30},
+		{"10m", 10 << 20},
+	}
+	for _, tt := range tests {
+		n, err := ParseSize(tt.in)
+		if err != nil {
+			t.Errorf("%q: %v", tt.in, err)
+			continue
+		}
+		if n != tt.exp {
+			t.Errorf("%q: got %d, want %d", tt.in, n, tt.exp)
+		}
+	}
+	for _, bad := range []string{"MB", "10 parsecs", "1.2.3"} {
+		if _, err := ParseSize(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestFilters(t *testing.T) {
+	f := Filters{
+		MaxSize: "1MB",
+		Exclude: []Rule{
+			{Name: "videos", ContentTypes: []string{"video/*"}},
+			{Glob: "*.zip", Folders: []string{"solutions"}},
+		},
+	}
+	if err := f.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		file   canvas.File
+		folder string
+		skip   string
+	}{
+		{canvas.File{Filename: "notes.pdf", Size: 10}, "lectures", ""},
+		{canvas.File{Filename: "big.pdf", Size: 2 << 20}, "lectures", "max_size 1MB"},
+		{canvas.File{Filename: "lecture.mp4", ContentType: "video/mp4"}, "", "exclude videos"},
+		{canvas.File{Filename: "hw1.zip"}, "Homework/Solutions", `exclude glob "*.zip" and folders solutions`},
+		{canvas.File{Filename: "hw1.zip"}, "Homework", ""},
+	}
+	for _, tt := range tests {
+		if skip := f.Skip(&tt.file, tt.folder); skip != tt.skip {
+			t.Errorf("%s: got %q, want %q", tt.file.Filename, skip, tt.skip)
+		}
+	}
+
+	course := f.merge(Filters{Include: []Rule{{Name: "pdfs", Regex: `\.pdf$`}}})
+	if skip := course.Skip(&canvas.File{Filename: "notes.txt"}, ""); skip == "" {
+		t.Error("files that match no include rule should be skipped")
+	}
+	if skip := course.Skip(&canvas.File{Filename: "notes.pdf"}, ""); skip != "" {
+		t.Errorf("should not skip an included file: %s", skip)
+	}
+	if len(f.Include) != 0 {
+		t.Error("merge should not change the global filters")
+	}
+	bad := Filters{Exclude: []Rule{{Regex: "("}}}
+	if bad.Validate() == nil {
+		t.Error("expected a bad regex to fail validation")
+	}
+}
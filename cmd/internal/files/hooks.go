@@ -0,0 +1,161 @@
+package files
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/harrybrwn/go-canvas"
+)
+
+// Hook is run after a file is downloaded or updated.
+type Hook struct {
+	Name string `yaml:"name"`
+	// Command is the program and its arguments. Each argument is
+	// a template that is given a HookEvent. The command is not run
+	// in a shell.
+	Command []string `yaml:"command"`
+	// Match is a regex that the local path has to match
+	// for the hook to run. Empty matches every file.
+	Match string `yaml:"match"`
+	// On is the list of actions ("new", "updated") that the hook
+	// runs for. Empty means both.
+	On []string `yaml:"on"`
+
+	// Func is called instead of Command if it is not nil.
+	Func func(*HookEvent) error `yaml:"-"`
+}
+
+func (h *Hook) String() string {
+	if h.Name != "" {
+		return h.Name
+	}
+	if len(h.Command) > 0 {
+		return h.Command[0]
+	}
+	return "hook"
+}
+
+// HookEvent describes the file that a hook is run for.
+type HookEvent struct {
+	Action Action
+	Course *canvas.Course
+	File   *canvas.File
+	// Path is the local path of the file.
+	Path string
+	// Dir is the directory of Path.
+	Dir string
+}
+
+// Env returns the environment variables given to hook commands.
+func (e *HookEvent) Env() []string {
+	return []string{
+		"EDU_ACTION=" + e.Action.String(),
+		"EDU_COURSE=" + e.Course.Name,
+		"EDU_COURSE_ID=" + strconv.Itoa(e.Course.ID),
+		"EDU_COURSE_CODE=" + e.Course.CourseCode,
+		"EDU_FILE_ID=" + strconv.Itoa(e.File.ID),
+		"EDU_FILE_NAME=" + e.File.Filename,
+		"EDU_FILE_SIZE=" + strconv.Itoa(e.File.Size),
+		"EDU_CONTENT_TYPE=" + e.File.ContentType,
+		"EDU_PATH=" + e.Path,
+		"EDU_DIR=" + e.Dir,
+	}
+}
+
+func (h *Hook) matches(e *HookEvent) (bool, error) {
+	if len(h.On) > 0 {
+		ok := false
+		for _, on := range h.On {
+			if strings.EqualFold(on, e.Action.String()) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	if h.Match == "" {
+		return true, nil
+	}
+	return regexp.MatchString(h.Match, e.Path)
+}
+
+// Run will run the hook for an event.
+func (h *Hook) Run(e *HookEvent) error {
+	if h.Func != nil {
+		return h.Func(e)
+	}
+	if len(h.Command) == 0 {
+		return errors.New("no command")
+	}
+	args := make([]string, len(h.Command))
+	for i, arg := range h.Command {
+		tmpl, err := template.New("hook").Funcs(templateFuncs).Parse(arg)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err = tmpl.Execute(&buf, e); err != nil {
+			return err
+		}
+		args[i] = buf.String()
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = e.Dir
+	cmd.Env = append(os.Environ(), e.Env()...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// hooks returns the global hooks followed by
+// the hooks for one course.
+func (cd *CourseDownloader) hooks(course *canvas.Course) []Hook {
+	hooks := cd.Hooks
+	for code, h := range cd.CourseHooks {
+		if strings.EqualFold(code, course.CourseCode) {
+			hooks = append(hooks[:len(hooks):len(hooks)], h...)
+		}
+	}
+	return hooks
+}
+
+// runHooks runs the hooks for a file that was just downloaded.
+// Failures are added to the report.
+func (cd *CourseDownloader) runHooks(course *canvas.Course, file *canvas.File, fullpath string, action Action) {
+	event := &HookEvent{
+		Action: action,
+		Course: course,
+		File:   file,
+		Path:   fullpath,
+		Dir:    filepath.Dir(fullpath),
+	}
+	for _, h := range cd.hooks(course) {
+		ok, err := h.matches(event)
+		if err == nil && ok {
+			err = h.Run(event)
+		}
+		if err != nil {
+			cd.Report.add(Record{
+				Action: HookFailed,
+				Course: course.Name,
+				Path:   cd.rel(fullpath),
+				Err:    fmt.Errorf("hook %s: %w", h.String(), err),
+			})
+		}
+	}
+}
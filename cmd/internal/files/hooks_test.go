@@ -0,0 +1,63 @@
+package files
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/harrybrwn/go-canvas"
+)
+
+func TestHooks(t *testing.T) {
+	var (
+		cd     = NewDownloader("/base")
+		course = &canvas.Course{Name: "CSE 100 01", CourseCode: "CSE 100"}
+		file   = &canvas.File{ID: 1, Filename: "slides.pptx"}
+		ran    []string
+	)
+	hook := func(name string, err error) func(*HookEvent) error {
+		return func(e *HookEvent) error {
+			if e.Path != "/base/cse100/slides.pptx" || e.Dir != "/base/cse100" {
+				t.Errorf("wrong paths: %s %s", e.Path, e.Dir)
+			}
+			ran = append(ran, name)
+			return err
+		}
+	}
+	cd.Hooks = []Hook{
+		{Name: "all", Func: hook("all", nil)},
+		{Name: "pdf", Match: `\.pdf$`, Func: hook("pdf", nil)},
+		{Name: "updated", On: []string{"updated"}, Func: hook("updated", nil)},
+	}
+	cd.CourseHooks = map[string][]Hook{
+		"cse 100": {{Name: "broken", Func: hook("broken", errors.New("oops"))}},
+		"CSE 101": {{Name: "other", Func: hook("other", nil)}},
+	}
+	cd.runHooks(course, file, "/base/cse100/slides.pptx", New)
+	if len(ran) != 2 || ran[0] != "all" || ran[1] != "broken" {
+		t.Errorf("wrong hooks were run: %v", ran)
+	}
+	if n := cd.Report.Count(HookFailed); n != 1 {
+		t.Errorf("expected one hook failure, got %d", n)
+	}
+	if len(cd.Hooks) != 3 {
+		t.Error("course hooks should not change the global hooks")
+	}
+}
+
+func TestHookCommand(t *testing.T) {
+	e := &HookEvent{
+		Action: Updated,
+		Course: &canvas.Course{Name: "Course"},
+		File:   &canvas.File{Filename: "notes.txt"},
+		Path:   "/tmp/notes.txt",
+		Dir:    "/tmp",
+	}
+	h := Hook{Command: []string{"sh", "-c", `test "$EDU_ACTION" = updated && test "$1" = notes.txt`, "sh", "{{.File.Filename}}"}}
+	if err := h.Run(e); err != nil {
+		t.Error(err)
+	}
+	h = Hook{Command: []string{"sh", "-c", "echo failed; exit 1"}}
+	if err := h.Run(e); err == nil {
+		t.Error("expected an error")
+	}
+}
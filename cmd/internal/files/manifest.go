@@ -0,0 +1,170 @@
+package files
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MetaDir is the name of the hidden directory inside the
+// base directory that holds the download manifest.
+const MetaDir = ".edu"
+
+const manifestFile = "manifest.json"
+
+// Entry is the manifest record for one downloaded canvas file.
+type Entry struct {
+	ID        int       `json:"id"`
+	CourseID  int       `json:"course_id"`
+	Path      string    `json:"path"` // relative to the base directory
+	Size      int64     `json:"size"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Checksum  string    `json:"checksum"`
+	// Source is empty for course files and is set for files
+	// found somewhere else, like submissions. Only course files
+	// can be orphaned.
+	Source string `json:"source,omitempty"`
+	// Versions are the previous copies of the file, oldest first.
+	Versions []Version `json:"versions,omitempty"`
+}
+
+func (e *Entry) copy() *Entry {
+	cp := *e
+	cp.Versions = append([]Version(nil), e.Versions...)
+	return &cp
+}
+
+// Manifest keeps track of every file downloaded into a base
+// directory keyed by the canvas file ID.
+type Manifest struct {
+	basedir string
+	mu      sync.Mutex
+	files   map[int]*Entry
+}
+
+// OpenManifest will read the manifest for a base directory. If there
+// is no manifest yet, an empty one is returned.
+func OpenManifest(basedir string) (*Manifest, error) {
+	m := &Manifest{
+		basedir: basedir,
+		files:   make(map[int]*Entry),
+	}
+	f, err := os.Open(m.filename())
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []*Entry
+	if err = json.NewDecoder(f).Decode(&entries); err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		m.files[e.ID] = e
+	}
+	return m, nil
+}
+
+func (m *Manifest) filename() string {
+	return filepath.Join(m.basedir, MetaDir, manifestFile)
+}
+
+// Get returns a copy of the entry for a canvas file ID
+// or nil if the file is not in the manifest.
+func (m *Manifest) Get(id int) *Entry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.files[id]
+	if !ok {
+		return nil
+	}
+	return e.copy()
+}
+
+// Set will add or replace a manifest entry.
+func (m *Manifest) Set(e *Entry) {
+	m.mu.Lock()
+	m.files[e.ID] = e
+	m.mu.Unlock()
+}
+
+func (m *Manifest) setSource(id int, source string) {
+	m.mu.Lock()
+	if e, ok := m.files[id]; ok {
+		e.Source = source
+	}
+	m.mu.Unlock()
+}
+
+// Remove deletes an entry from the manifest.
+func (m *Manifest) Remove(id int) {
+	m.mu.Lock()
+	delete(m.files, id)
+	m.mu.Unlock()
+}
+
+// Entries returns all the entries sorted by path.
+func (m *Manifest) Entries() []*Entry {
+	m.mu.Lock()
+	entries := make([]*Entry, 0, len(m.files))
+	for _, e := range m.files {
+		entries = append(entries, e.copy())
+	}
+	m.mu.Unlock()
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+	return entries
+}
+
+// Fullpath returns the absolute path of an entry.
+func (m *Manifest) Fullpath(e *Entry) string {
+	return filepath.Join(m.basedir, e.Path)
+}
+
+// Save writes the manifest to disk.
+func (m *Manifest) Save() error {
+	entries := m.Entries()
+	if err := mkdir(filepath.Join(m.basedir, MetaDir)); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Join(m.basedir, MetaDir), manifestFile)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(tmp)
+	enc.SetIndent("", "  ")
+	if err = enc.Encode(entries); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), m.filename())
+}
+
+// checksum returns the hex encoded sha256 sum of a file.
+func checksum(filename string) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err = io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,104 @@
+package files
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "edu-manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m, err := OpenManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Entries()) != 0 {
+		t.Error("new manifest should be empty")
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	m.Set(&Entry{ID: 2, Path: "b/file.pdf", Size: 10, UpdatedAt: now})
+	m.Set(&Entry{ID: 1, Path: "a/file.pdf", Size: 5, UpdatedAt: now})
+	if err = m.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err = OpenManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := m.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	if entries[0].Path != "a/file.pdf" {
+		t.Errorf("entries should be sorted by path, got %s first", entries[0].Path)
+	}
+	e := m.Get(2)
+	if e == nil {
+		t.Fatal("could not find entry 2")
+	}
+	if e.Size != 10 || !e.UpdatedAt.Equal(now) {
+		t.Errorf("wrong entry: %+v", e)
+	}
+	e.Path = "changed"
+	if m.Get(2).Path == "changed" {
+		t.Error("Get should return a copy")
+	}
+	m.Remove(2)
+	if m.Get(2) != nil {
+		t.Error("entry should have been removed")
+	}
+}
+
+func TestVersions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "edu-versions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	m, err := OpenManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &Entry{ID: 7, Path: "notes.txt", UpdatedAt: time.Now()}
+	if err = ioutil.WriteFile(m.Fullpath(e), []byte("first"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m.Set(e)
+	if err = m.StashVersion(e); err != nil {
+		t.Fatal(err)
+	}
+	if exists(m.Fullpath(e)) {
+		t.Error("stashed file should be moved out of the way")
+	}
+	if len(m.Get(7).Versions) != 1 {
+		t.Fatal("expected one version")
+	}
+	if err = ioutil.WriteFile(m.Fullpath(e), []byte("second"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := m.Find(m.Fullpath(e))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = m.Restore(found.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(m.Fullpath(e))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "first" {
+		t.Errorf("restored wrong content: %q", b)
+	}
+	if len(m.Get(7).Versions) != 2 {
+		t.Error("restoring should save the current copy as a version")
+	}
+}
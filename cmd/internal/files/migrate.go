@@ -0,0 +1,173 @@
+package files
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/harrybrwn/go-canvas"
+)
+
+// Move is a planned move of a downloaded file. Both
+// paths are relative to the base directory.
+type Move struct {
+	ID     int
+	Course string
+	From   string
+	To     string
+}
+
+// Plan will find the files of a course that are in the manifest but
+// would be downloaded to a different path with the current path
+// template and replacement patterns.
+func (cd *CourseDownloader) Plan(course *canvas.Course, reps []Replacement) ([]Move, error) {
+	if cd.Manifest == nil {
+		return nil, nil
+	}
+	var (
+		moves      []Move
+		courseTerm = cd.term(course)
+		pairs      = cd.filesGenerator(course)
+	)
+	defer drain(pairs)
+	for pair := range pairs {
+		if pair.err != nil {
+			return nil, pair.err
+		}
+		entry := cd.Manifest.Get(pair.file.ID)
+		if entry == nil {
+			continue
+		}
+		path, err := cd.destination(course, courseTerm, pair)
+		if err != nil {
+			return nil, err
+		}
+		if path, err = DoReplacements(reps, path); err != nil {
+			return nil, err
+		}
+		to := cd.rel(path)
+		if to == entry.Path || !exists(cd.Manifest.Fullpath(entry)) {
+			continue
+		}
+		moves = append(moves, Move{ID: entry.ID, Course: course.Name, From: entry.Path, To: to})
+	}
+	return moves, nil
+}
+
+// Collisions returns a description of every move that would
+// overwrite another file. The result is empty if the moves
+// are safe.
+func (cd *CourseDownloader) Collisions(moves []Move) []string {
+	var (
+		collisions []string
+		targets    = make(map[string][]string)
+		sources    = make(map[string]bool)
+	)
+	for _, m := range moves {
+		targets[m.To] = append(targets[m.To], m.From)
+		sources[m.From] = true
+	}
+	for _, m := range moves {
+		froms := targets[m.To]
+		switch {
+		case len(froms) > 1:
+			if froms[0] == m.From {
+				collisions = append(collisions, fmt.Sprintf(
+					"%s: %d files would be moved here (%s)", m.To, len(froms), strings.Join(froms, ", ")))
+			}
+		case exists(filepath.Join(cd.basedir, m.To)) && !sources[m.To]:
+			collisions = append(collisions, fmt.Sprintf("%s: would overwrite an existing file (from %s)", m.To, m.From))
+		}
+	}
+	sort.Strings(collisions)
+	return collisions
+}
+
+// WritePlan writes a list of moves.
+func WritePlan(w io.Writer, moves []Move) {
+	for _, m := range moves {
+		fmt.Fprintf(w, "%s => %s\n", m.From, m.To)
+	}
+}
+
+// Migrate will move files according to a plan, update the manifest,
+// and remove any directories that were left empty. Nothing is moved
+// if any of the moves would overwrite another file.
+func (cd *CourseDownloader) Migrate(moves []Move) error {
+	if cd.Manifest == nil {
+		return fmt.Errorf("cannot migrate files without a manifest")
+	}
+	if c := cd.Collisions(moves); len(c) > 0 {
+		return fmt.Errorf("refusing to migrate, %d collision(s):\n  %s", len(c), strings.Join(c, "\n  "))
+	}
+	// files that are moved to the old path of another file
+	// have to wait until that file has been moved
+	pending := moves
+	for len(pending) > 0 {
+		var next []Move
+		for _, m := range pending {
+			if exists(filepath.Join(cd.basedir, m.To)) {
+				next = append(next, m)
+				continue
+			}
+			if err := cd.migrate(m); err != nil {
+				return err
+			}
+		}
+		if len(next) == len(pending) {
+			return fmt.Errorf("cannot migrate %s: destination is still in use", next[0].From)
+		}
+		pending = next
+	}
+	return nil
+}
+
+func (cd *CourseDownloader) migrate(m Move) error {
+	entry := cd.Manifest.Get(m.ID)
+	if entry == nil {
+		return fmt.Errorf("%s: not in the manifest", m.From)
+	}
+	from := filepath.Join(cd.basedir, m.From)
+	to := filepath.Join(cd.basedir, m.To)
+	if err := mkdir(filepath.Dir(to)); err != nil {
+		return err
+	}
+	if err := os.Rename(from, to); err != nil {
+		return err
+	}
+	entry.Path = m.To
+	cd.Manifest.Set(entry)
+	cd.Report.add(Record{Action: Moved, Course: m.Course, Path: m.To, From: m.From})
+	return cd.pruneDirs(filepath.Dir(from))
+}
+
+// pruneDirs removes dir and its parents while they are
+// empty, stopping at the base directory.
+func (cd *CourseDownloader) pruneDirs(dir string) error {
+	base := filepath.Clean(cd.basedir)
+	for dir = filepath.Clean(dir); dir != base && strings.HasPrefix(dir, base); dir = filepath.Dir(dir) {
+		names, err := readdirnames(dir)
+		if err != nil {
+			return err
+		}
+		if len(names) > 0 {
+			return nil
+		}
+		if err = os.Remove(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readdirnames(dir string) ([]string, error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Readdirnames(-1)
+}
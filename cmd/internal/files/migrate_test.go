@@ -0,0 +1,76 @@
+package files
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "edu-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"old/a/one.pdf", "old/a/two.pdf", "keep/three.pdf"} {
+		p := filepath.Join(dir, name)
+		if err = os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(p, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cd := NewDownloader(dir)
+	if cd.Manifest, err = OpenManifest(dir); err != nil {
+		t.Fatal(err)
+	}
+	cd.Manifest.Set(&Entry{ID: 1, Path: "old/a/one.pdf"})
+	cd.Manifest.Set(&Entry{ID: 2, Path: "old/a/two.pdf"})
+	cd.Manifest.Set(&Entry{ID: 3, Path: "keep/three.pdf"})
+
+	bad := [][]Move{
+		{{ID: 1, From: "old/a/one.pdf", To: "new/x.pdf"}, {ID: 2, From: "old/a/two.pdf", To: "new/x.pdf"}},
+		{{ID: 1, From: "old/a/one.pdf", To: "keep/three.pdf"}},
+	}
+	for _, moves := range bad {
+		if len(cd.Collisions(moves)) == 0 {
+			t.Errorf("expected a collision for %v", moves)
+		}
+		if err = cd.Migrate(moves); err == nil {
+			t.Error("expected migrate to refuse")
+		}
+		if !exists(filepath.Join(dir, "old/a/one.pdf")) {
+			t.Fatal("files should not be moved when there is a collision")
+		}
+	}
+
+	moves := []Move{
+		{ID: 1, From: "old/a/one.pdf", To: "new/one.pdf"},
+		{ID: 2, From: "old/a/two.pdf", To: "new/two.pdf"},
+	}
+	if c := cd.Collisions(moves); len(c) != 0 {
+		t.Fatalf("unexpected collisions: %v", c)
+	}
+	if err = cd.Migrate(moves); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range moves {
+		if !exists(filepath.Join(dir, m.To)) {
+			t.Errorf("%s was not moved", m.To)
+		}
+		if p := cd.Manifest.Get(m.ID).Path; p != m.To {
+			t.Errorf("manifest path not updated: got %s, want %s", p, m.To)
+		}
+	}
+	if exists(filepath.Join(dir, "old")) {
+		t.Error("empty directories should be removed")
+	}
+	if !exists(filepath.Join(dir, "keep")) {
+		t.Error("non-empty directories should not be removed")
+	}
+	if n := cd.Report.Count(Moved); n != 2 {
+		t.Errorf("expected 2 moves in the report, got %d", n)
+	}
+}
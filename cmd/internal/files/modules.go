@@ -0,0 +1,204 @@
+package files
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/harrybrwn/edu/cmd/internal/rest"
+	"github.com/harrybrwn/go-canvas"
+)
+
+// Module is a course module.
+type Module struct {
+	ID         int          `json:"id"`
+	Name       string       `json:"name"`
+	ItemsCount int          `json:"items_count"`
+	Items      []ModuleItem `json:"items"`
+}
+
+// ModuleItem is one item in a module.
+type ModuleItem struct {
+	Title       string `json:"title"`
+	Type        string `json:"type"`
+	ContentID   int    `json:"content_id"`
+	PageURL     string `json:"page_url"`
+	ExternalURL string `json:"external_url"`
+	Indent      int    `json:"indent"`
+}
+
+type page struct {
+	ID    int    `json:"page_id"`
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// crawlModules finds the files in a course by looking through the
+// course modules and the file links in pages and assignments. This
+// is used for courses that have the files tab hidden. Files found
+// in a module are put in a folder named after the module.
+func (cd *CourseDownloader) crawlModules(course *canvas.Course, modules []Module, filters Filters, ch chan<- *filePathPair) {
+	var (
+		handle = cd.errorHandler(course)
+		seen   = make(map[int]bool)
+		bodies = make(map[string]string)
+		base   = filepath.Join(cd.basedir, course.Name)
+	)
+	send := func(id int, dir string) {
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		file, err := getFile(id)
+		if err != nil {
+			handle(err)
+			return
+		}
+		ch <- &filePathPair{
+			file:   file,
+			folder: dir,
+			path:   filepath.Join(base, dir, file.Filename),
+			skip:   filters.Skip(file, dir),
+		}
+	}
+	pageBody := func(pageURL string) string {
+		if body, ok := bodies[pageURL]; ok {
+			return body
+		}
+		var p page
+		err := rest.Get(rest.Path("courses", course.ID, "pages", pageURL), nil, &p)
+		if err != nil {
+			handle(err)
+		}
+		bodies[pageURL] = p.Body
+		return p.Body
+	}
+
+	for _, m := range modules {
+		dir := cleanName(m.Name)
+		for _, item := range m.Items {
+			switch item.Type {
+			case "File":
+				send(item.ContentID, dir)
+			case "Page":
+				for _, id := range fileLinks(pageBody(item.PageURL)) {
+					send(id, dir)
+				}
+			case "Assignment":
+				as, err := course.Assignment(item.ContentID)
+				if err != nil {
+					handle(err)
+					continue
+				}
+				for _, id := range fileLinks(as.Description) {
+					send(id, dir)
+				}
+			}
+		}
+	}
+
+	// files linked from pages and assignments that are not in a module
+	err := rest.Pages(rest.Path("courses", course.ID, "pages"), nil, func(b []byte) error {
+		var pages []page
+		if err := json.Unmarshal(b, &pages); err != nil {
+			return err
+		}
+		for _, p := range pages {
+			for _, id := range fileLinks(pageBody(p.URL)) {
+				send(id, "pages")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		handle(err)
+	}
+	for as := range course.Assignments() {
+		for _, id := range fileLinks(as.Description) {
+			send(id, "assignments")
+		}
+	}
+}
+
+// CourseModules gets all the modules in a course along with
+// their items.
+func CourseModules(courseID int) ([]Module, error) {
+	var modules []Module
+	params := url.Values{"include[]": {"items"}}
+	err := rest.Pages(rest.Path("courses", courseID, "modules"), params, func(b []byte) error {
+		var page []Module
+		if err := json.Unmarshal(b, &page); err != nil {
+			return err
+		}
+		modules = append(modules, page...)
+		return nil
+	})
+	if err != nil {
+		return modules, err
+	}
+	for i, m := range modules {
+		if m.Items != nil && len(m.Items) >= m.ItemsCount {
+			continue
+		}
+		// canvas leaves out the items if there are too many
+		modules[i].Items = nil
+		err = rest.Pages(rest.Path("courses", courseID, "modules", m.ID, "items"), nil, func(b []byte) error {
+			var items []ModuleItem
+			if err := json.Unmarshal(b, &items); err != nil {
+				return err
+			}
+			modules[i].Items = append(modules[i].Items, items...)
+			return nil
+		})
+		if err != nil {
+			return modules, err
+		}
+	}
+	return modules, nil
+}
+
+func getFile(id int) (*canvas.File, error) {
+	file := &canvas.File{}
+	return file, rest.Get(rest.Path("files", id), nil, file)
+}
+
+var fileLinkRegex = regexp.MustCompile(`/files/([0-9]+)`)
+
+// fileLinks returns the IDs of all the canvas files linked in some html.
+func fileLinks(html string) []int {
+	var (
+		ids  []int
+		seen = make(map[int]bool)
+	)
+	for _, m := range fileLinkRegex.FindAllStringSubmatch(html, -1) {
+		id, err := strconv.Atoi(m[1])
+		if err != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// cleanName makes a module name safe to use as a folder name. Path
+// separators from any OS are replaced and leading dots are removed
+// so that the name can never point outside of its parent folder.
+func cleanName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', 0:
+			return '-'
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(name), "."))
+	if name == "" {
+		return "untitled"
+	}
+	return name
+}
@@ -0,0 +1,39 @@
+package files
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFileLinks(t *testing.T) {
+	html := `<p><a href="https://canvas.instructure.com/courses/12/files/345/download?wrap=1"
+	data-api-endpoint="https://canvas.instructure.com/api/v1/courses/12/files/345">notes</a>
+	<img src="/courses/12/files/678/preview"> <a href="/courses/12/pages/home">home</a></p>`
+	ids := fileLinks(html)
+	if !reflect.DeepEqual(ids, []int{345, 678}) {
+		t.Errorf("wrong file ids: %v", ids)
+	}
+	if len(fileLinks("no links here")) != 0 {
+		t.Error("expected no links")
+	}
+}
+
+func TestCleanName(t *testing.T) {
+	tests := []struct {
+		name, exp string
+	}{
+		{"Week 1: Intro", "Week 1: Intro"},
+		{" Labs/Homework ", "Labs-Homework"},
+		{`Notes\Slides`, "Notes-Slides"},
+		{"", "untitled"},
+		{".", "untitled"},
+		{"..", "untitled"},
+		{"../../etc", "-..-etc"},
+		{".hidden", "hidden"},
+	}
+	for _, tst := range tests {
+		if res := cleanName(tst.name); res != tst.exp {
+			t.Errorf("cleanName(%q) = %q; want %q", tst.name, res, tst.exp)
+		}
+	}
+}
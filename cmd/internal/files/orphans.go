@@ -0,0 +1,94 @@
+package files
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// TrashDir is the directory inside the base directory that
+// pruned files are moved to.
+var TrashDir = filepath.Join(MetaDir, "trash")
+
+// listing keeps track of the files seen in each
+// course's file listing.
+type listing struct {
+	files   map[int]struct{}
+	courses map[int]bool // false if the listing had errors
+}
+
+func (cd *CourseDownloader) listCourse(courseID int) {
+	cd.mu.Lock()
+	if _, ok := cd.listing.courses[courseID]; !ok {
+		cd.listing.courses[courseID] = true
+	}
+	cd.mu.Unlock()
+}
+
+func (cd *CourseDownloader) listFailed(courseID int) {
+	cd.mu.Lock()
+	cd.listing.courses[courseID] = false
+	cd.mu.Unlock()
+}
+
+func (cd *CourseDownloader) see(fileID int) {
+	cd.mu.Lock()
+	cd.listing.files[fileID] = struct{}{}
+	cd.mu.Unlock()
+}
+
+func (cd *CourseDownloader) seen(fileID int) bool {
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+	_, ok := cd.listing.files[fileID]
+	return ok
+}
+
+// Orphans returns the manifest entries for local files that are
+// no longer in their course's file listing. Only courses that have
+// been downloaded without errors are checked.
+func (cd *CourseDownloader) Orphans() []*Entry {
+	if cd.Manifest == nil {
+		return nil
+	}
+	cd.mu.Lock()
+	defer cd.mu.Unlock()
+	var orphans []*Entry
+	for _, e := range cd.Manifest.Entries() {
+		if e.Source != "" || !cd.listing.courses[e.CourseID] {
+			continue
+		}
+		if _, ok := cd.listing.files[e.ID]; ok {
+			continue
+		}
+		if !exists(cd.Manifest.Fullpath(e)) {
+			continue
+		}
+		orphans = append(orphans, e)
+	}
+	return orphans
+}
+
+// Prune moves files into the trash directory and
+// removes them from the manifest.
+func (cd *CourseDownloader) Prune(entries []*Entry) error {
+	for _, e := range entries {
+		from := cd.Manifest.Fullpath(e)
+		to := filepath.Join(cd.basedir, TrashDir, e.Path)
+		if exists(to) {
+			to = fmt.Sprintf("%s.%s", to, time.Now().UTC().Format("20060102T150405Z"))
+		}
+		if err := mkdir(filepath.Dir(to)); err != nil {
+			return err
+		}
+		if err := os.Rename(from, to); err != nil {
+			return err
+		}
+		cd.Manifest.Remove(e.ID)
+		if err := cd.pruneDirs(filepath.Dir(from)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
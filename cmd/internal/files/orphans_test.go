@@ -0,0 +1,127 @@
+package files
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/harrybrwn/go-canvas"
+)
+
+func TestOrphans(t *testing.T) {
+	dir, err := ioutil.TempDir("", "edu-orphans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cd := NewDownloader(dir)
+	if cd.Manifest, err = OpenManifest(dir); err != nil {
+		t.Fatal(err)
+	}
+	entries := []*Entry{
+		{ID: 1, CourseID: 10, Path: "c10/current.pdf"},
+		{ID: 2, CourseID: 10, Path: "c10/deleted/old.pdf"},
+		{ID: 3, CourseID: 20, Path: "c20/failed.pdf"},
+		{ID: 4, CourseID: 30, Path: "c30/not-listed.pdf"},
+		{ID: 5, CourseID: 10, Path: "c10/submissions/hw1/attempt-1/hw1.pdf", Source: sourceSubmission},
+	}
+	for _, e := range entries {
+		p := cd.Manifest.Fullpath(e)
+		if err = os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(p, []byte(e.Path), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cd.Manifest.Set(e)
+	}
+	cd.listCourse(10)
+	cd.see(1)
+	cd.listCourse(20)
+	cd.listFailed(20)
+
+	orphans := cd.Orphans()
+	if len(orphans) != 1 || orphans[0].ID != 2 {
+		t.Fatalf("expected only file 2 to be an orphan, got %v", orphans)
+	}
+	if err = cd.Prune(orphans); err != nil {
+		t.Fatal(err)
+	}
+	if !exists(filepath.Join(dir, TrashDir, "c10/deleted/old.pdf")) {
+		t.Error("orphan should be in the trash")
+	}
+	if exists(filepath.Join(dir, "c10/deleted")) {
+		t.Error("empty directory should be removed")
+	}
+	if cd.Manifest.Get(2) != nil {
+		t.Error("orphan should be removed from the manifest")
+	}
+	if len(cd.Orphans()) != 0 {
+		t.Error("should not have any orphans after pruning")
+	}
+}
+
+func TestPruneAfterFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "edu-orphans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cd := NewDownloader(dir)
+	cd.Stderr = ioutil.Discard
+	if cd.Manifest, err = OpenManifest(dir); err != nil {
+		t.Fatal(err)
+	}
+	if cd.PathTemplate, err = ParseTemplate("{{.Course.Missing}}/{{.File.Filename}}"); err != nil {
+		t.Fatal(err)
+	}
+	course := &canvas.Course{ID: 10, Name: "c10"}
+	for _, e := range []*Entry{
+		{ID: 1, CourseID: 10, Path: "c10/a.pdf"},
+		{ID: 2, CourseID: 10, Path: "c10/deleted.pdf"},
+	} {
+		p := cd.Manifest.Fullpath(e)
+		if err = os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(p, []byte(e.Path), 0644); err != nil {
+			t.Fatal(err)
+		}
+		cd.Manifest.Set(e)
+	}
+
+	pairs := make(chan *filePathPair)
+	go func() {
+		defer close(pairs)
+		for _, id := range []int{1, 3} {
+			pairs <- &filePathPair{file: &canvas.File{ID: id, Filename: "a.pdf"}, path: filepath.Join(dir, "c10/a.pdf")}
+		}
+		pairs <- &filePathPair{file: &canvas.File{ID: 4}, path: filepath.Join(dir, "c10/b.mp4"), skip: "exclude videos"}
+	}()
+	cd.listCourse(course.ID)
+	cd.downloadPairs(course, Term{}, pairs, nil)
+	cd.Wait()
+
+	var failed int
+	for _, rec := range cd.Report.Records() {
+		if rec.Action == Failed {
+			failed++
+		}
+	}
+	if failed != 2 {
+		t.Errorf("expected every file to fail, got %d failures", failed)
+	}
+	if n := cd.Report.Count(Filtered); n != 1 {
+		t.Errorf("expected 1 filtered file, got %d", n)
+	}
+	if orphans := cd.Orphans(); len(orphans) != 0 {
+		t.Fatalf("a course with errors should not have orphans, got %v", orphans)
+	}
+	if err = cd.Prune(cd.Orphans()); err != nil {
+		t.Fatal(err)
+	}
+	if exists(filepath.Join(dir, TrashDir)) || !exists(filepath.Join(dir, "c10/deleted.pdf")) {
+		t.Error("nothing should be trashed after a template error")
+	}
+}
@@ -0,0 +1,136 @@
+package files
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/harrybrwn/edu/pkg/term"
+	"github.com/harrybrwn/go-canvas"
+)
+
+// partialExt is added to the name of files that
+// are still being downloaded.
+const partialExt = ".part"
+
+var partials = struct {
+	sync.Mutex
+	files map[string]struct{}
+}{files: make(map[string]struct{})}
+
+// CleanPartials will remove all the partial files for
+// downloads that are still in progress. It should be called
+// when the program is interrupted.
+func CleanPartials() {
+	partials.Lock()
+	defer partials.Unlock()
+	for name := range partials.files {
+		os.Remove(name)
+		delete(partials.files, name)
+	}
+}
+
+// writePartial writes a canvas file to a partial file. If the partial
+// file already exists from an earlier run, then the download is resumed
+// with a range request.
+func writePartial(file *canvas.File, part string, bar *term.Bar) (err error) {
+	partials.Lock()
+	partials.files[part] = struct{}{}
+	partials.Unlock()
+	defer func() {
+		partials.Lock()
+		delete(partials.files, part)
+		partials.Unlock()
+	}()
+
+	osfile, err := os.OpenFile(part, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := osfile.Close(); e != nil && err == nil {
+			err = e
+		}
+	}()
+	stat, err := osfile.Stat()
+	if err != nil {
+		return err
+	}
+	offset := stat.Size()
+	if file.Size > 0 && offset > int64(file.Size) {
+		offset = 0
+	}
+
+	var w io.Writer = osfile
+	if bar != nil {
+		w = io.MultiWriter(osfile, bar)
+	}
+
+	resumed := false
+	if offset > 0 && file.URL != "" {
+		resumed, err = resume(file.URL, osfile, w, offset)
+		if err != nil {
+			return err
+		}
+		if resumed && bar != nil {
+			bar.Add(offset)
+		}
+	}
+	if !resumed {
+		if err = osfile.Truncate(0); err != nil {
+			return err
+		}
+		if _, err = osfile.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err = file.WriteTo(w); err != nil {
+			return err
+		}
+	}
+
+	if file.Size > 0 {
+		if stat, err = osfile.Stat(); err != nil {
+			return err
+		}
+		if stat.Size() != int64(file.Size) {
+			if stat.Size() > int64(file.Size) {
+				// can't resume from a file that is too big
+				os.Remove(part)
+			}
+			return fmt.Errorf("%s: downloaded %d bytes, expected %d", file.Filename, stat.Size(), file.Size)
+		}
+	}
+	return nil
+}
+
+// resume will request the rest of a file starting at offset and append
+// it to the partial file using w. It returns false if the server does
+// not support range requests.
+func resume(url string, osfile *os.File, w io.Writer, offset int64) (bool, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		break
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the partial file is already complete
+		return true, nil
+	default:
+		return false, nil
+	}
+	if _, err = osfile.Seek(offset, io.SeekStart); err != nil {
+		return false, err
+	}
+	_, err = io.Copy(w, resp.Body)
+	return true, err
+}
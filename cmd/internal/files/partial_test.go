@@ -0,0 +1,49 @@
+package files
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResume(t *testing.T) {
+	content := []byte("this is the full content of the file")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "file.txt", time.Now(), bytes.NewReader(content))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "edu-partial")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	part := filepath.Join(dir, "file.txt"+partialExt)
+	if err = ioutil.WriteFile(part, content[:10], 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(part, os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := resume(srv.URL, f, f, 10)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("server supports range requests, download should have been resumed")
+	}
+	b, err := ioutil.ReadFile(part)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, content) {
+		t.Errorf("wrong content after resume: %q", b)
+	}
+}
@@ -0,0 +1,163 @@
+package files
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Action is something the downloader did with a file.
+type Action int
+
+const (
+	// Unchanged means the local file is up to date.
+	Unchanged Action = iota
+	// New means the file was downloaded for the first time.
+	New
+	// Updated means the file was downloaded again because
+	// it changed on canvas.
+	Updated
+	// Moved means the local file was moved to a new path.
+	Moved
+	// Failed means there was an error handling the file.
+	Failed
+	// HookFailed means that the file was downloaded but
+	// one of the post-download hooks failed.
+	HookFailed
+	// Filtered means the file was skipped by a filter rule.
+	Filtered
+)
+
+func (a Action) String() string {
+	switch a {
+	case Unchanged:
+		return "unchanged"
+	case New:
+		return "new"
+	case Updated:
+		return "updated"
+	case Moved:
+		return "moved"
+	case Failed:
+		return "failed"
+	case HookFailed:
+		return "hook failed"
+	case Filtered:
+		return "filtered"
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+// Record is one line in a Report.
+type Record struct {
+	Action Action
+	Course string
+	Path   string
+	From   string // only set for moved files
+	Err    error
+}
+
+// Report collects everything the downloader did.
+type Report struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+func (r *Report) add(rec Record) {
+	r.mu.Lock()
+	r.records = append(r.records, rec)
+	r.mu.Unlock()
+}
+
+// Records returns all the records sorted by path.
+func (r *Report) Records() []Record {
+	r.mu.Lock()
+	recs := make([]Record, len(r.records))
+	copy(recs, r.records)
+	r.mu.Unlock()
+	sort.Slice(recs, func(i, j int) bool {
+		return recs[i].Path < recs[j].Path
+	})
+	return recs
+}
+
+// Count returns the number of records with a given action.
+func (r *Report) Count(a Action) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, rec := range r.records {
+		if rec.Action == a {
+			n++
+		}
+	}
+	return n
+}
+
+// CourseSummary is the number of files that
+// were handled in each way for one course.
+type CourseSummary struct {
+	Course string
+	Counts map[Action]int
+}
+
+// Courses returns a summary for each course sorted by course name.
+func (r *Report) Courses() []CourseSummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	index := make(map[string]int)
+	summaries := make([]CourseSummary, 0)
+	for _, rec := range r.records {
+		i, ok := index[rec.Course]
+		if !ok {
+			i = len(summaries)
+			index[rec.Course] = i
+			summaries = append(summaries, CourseSummary{
+				Course: rec.Course,
+				Counts: make(map[Action]int),
+			})
+		}
+		summaries[i].Counts[rec.Action]++
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Course < summaries[j].Course
+	})
+	return summaries
+}
+
+// Summary returns a one line summary of the report.
+func (r *Report) Summary() string {
+	parts := make([]string, 0, 7)
+	for _, a := range []Action{New, Updated, Moved, Unchanged, Filtered, Failed, HookFailed} {
+		parts = append(parts, fmt.Sprintf("%d %s", r.Count(a), a))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// WriteTo writes every record that is not Unchanged or Filtered.
+func (r *Report) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, rec := range r.Records() {
+		var (
+			n   int
+			err error
+		)
+		switch rec.Action {
+		case Unchanged, Filtered:
+			continue
+		case Moved:
+			n, err = fmt.Fprintf(w, "%-9s %s => %s\n", rec.Action, rec.From, rec.Path)
+		case Failed, HookFailed:
+			n, err = fmt.Fprintf(w, "%-9s %s: %v\n", rec.Action, rec.Path, rec.Err)
+		default:
+			n, err = fmt.Fprintf(w, "%-9s %s\n", rec.Action, rec.Path)
+		}
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
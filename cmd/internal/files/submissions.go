@@ -0,0 +1,118 @@
+package files
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	"github.com/harrybrwn/edu/cmd/internal/rest"
+	"github.com/harrybrwn/go-canvas"
+)
+
+// SubmissionsDir is the folder in each course that
+// submission files are downloaded to.
+const SubmissionsDir = "submissions"
+
+const sourceSubmission = "submission"
+
+type submission struct {
+	Attempt     int           `json:"attempt"`
+	Attachments []canvas.File `json:"attachments"`
+	History     []struct {
+		Attempt     int           `json:"attempt"`
+		Attachments []canvas.File `json:"attachments"`
+	} `json:"submission_history"`
+	Comments []struct {
+		Author      string        `json:"author_name"`
+		Attachments []canvas.File `json:"attachments"`
+	} `json:"submission_comments"`
+}
+
+// DownloadSubmissions will download the files for every assignment in
+// a course into 'submissions/<assignment>' in the course folder. This
+// includes the user's own submitted files ('attempt-<n>'), the files
+// attached to submission comments ('feedback'), and the files linked in
+// the assignment description ('files'). It should be called after
+// Download so that course files are not downloaded twice.
+func (cd *CourseDownloader) DownloadSubmissions(course *canvas.Course, reps []Replacement) error {
+	cd.downloadPairs(course, cd.term(course), cd.submissionsGenerator(course), reps)
+	return nil
+}
+
+func (cd *CourseDownloader) submissionsGenerator(course *canvas.Course) <-chan *filePathPair {
+	ch := make(chan *filePathPair)
+	go func() {
+		defer close(ch)
+		var (
+			warn    = cd.warnHandler(course)
+			filters = cd.filters(course)
+			base    = filepath.Join(cd.basedir, course.Name)
+		)
+		course.SetErrorHandler(warn)
+		send := func(file *canvas.File, dir string) {
+			// files in the course files or found in
+			// another assignment are only downloaded once
+			if cd.seen(file.ID) {
+				return
+			}
+			cd.see(file.ID)
+			ch <- &filePathPair{
+				file:   file,
+				folder: dir,
+				path:   filepath.Join(base, dir, file.Filename),
+				skip:   filters.Skip(file, dir),
+				source: sourceSubmission,
+			}
+		}
+		for as := range course.Assignments() {
+			dir := filepath.Join(SubmissionsDir, cleanName(as.Name))
+			for _, id := range fileLinks(as.Description) {
+				if cd.seen(id) {
+					continue
+				}
+				file, err := getFile(id)
+				if err != nil {
+					warn(err)
+					continue
+				}
+				send(file, filepath.Join(dir, "files"))
+			}
+
+			sub, err := getSubmission(course.ID, as.ID)
+			if err != nil {
+				warn(err)
+				continue
+			}
+			if len(sub.History) == 0 {
+				for i := range sub.Attachments {
+					send(&sub.Attachments[i], filepath.Join(dir, attemptDir(sub.Attempt)))
+				}
+			}
+			for _, h := range sub.History {
+				for i := range h.Attachments {
+					send(&h.Attachments[i], filepath.Join(dir, attemptDir(h.Attempt)))
+				}
+			}
+			for _, c := range sub.Comments {
+				for i := range c.Attachments {
+					send(&c.Attachments[i], filepath.Join(dir, "feedback"))
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+func getSubmission(courseID, assignmentID int) (*submission, error) {
+	sub := &submission{}
+	params := url.Values{"include[]": {"submission_comments", "submission_history"}}
+	path := rest.Path("courses", courseID, "assignments", assignmentID, "submissions", "self")
+	return sub, rest.Get(path, params, sub)
+}
+
+func attemptDir(attempt int) string {
+	if attempt < 1 {
+		attempt = 1
+	}
+	return fmt.Sprintf("attempt-%d", attempt)
+}
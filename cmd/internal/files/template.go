@@ -0,0 +1,102 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/harrybrwn/edu/cmd/internal/rest"
+	"github.com/harrybrwn/go-canvas"
+)
+
+// Term is the enrollment term of a course.
+type Term struct {
+	ID      int       `json:"id"`
+	Name    string    `json:"name"`
+	StartAt time.Time `json:"start_at"`
+	EndAt   time.Time `json:"end_at"`
+}
+
+// PathData is the data given to a path template.
+type PathData struct {
+	Course *canvas.Course
+	Term   Term
+	// Folder is the canvas folder (or module name) of the file
+	// without the "course files" prefix.
+	Folder string
+	File   *canvas.File
+}
+
+var templateFuncs = template.FuncMap{
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"title":   strings.Title,
+	"trim":    strings.TrimSpace,
+	"clean":   cleanName,
+	"replace": func(old, new, s string) string { return strings.Replace(s, old, new, -1) },
+	"nospace": func(s string) string { return strings.Replace(s, " ", "_", -1) },
+}
+
+// ParseTemplate parses a path template. The template is executed with
+// a PathData and the result is a path relative to the base directory.
+//
+//	{{.Course.CourseCode | lower}}/{{.Folder}}/{{.File.Filename}}
+func ParseTemplate(text string) (*template.Template, error) {
+	return template.New("path_template").
+		Funcs(templateFuncs).
+		Option("missingkey=error").
+		Parse(text)
+}
+
+// destination returns the path that a file should be downloaded to
+// before the replacement patterns are applied.
+func (cd *CourseDownloader) destination(course *canvas.Course, term Term, pair *filePathPair) (string, error) {
+	if cd.PathTemplate == nil {
+		return pair.path, nil
+	}
+	var buf bytes.Buffer
+	err := cd.PathTemplate.Execute(&buf, &PathData{
+		Course: course,
+		Term:   term,
+		Folder: pair.folder,
+		File:   pair.file,
+	})
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(cd.basedir, filepath.FromSlash(buf.String()))
+	// canvas names can have ".." in them which could put
+	// the file anywhere
+	rel, err := filepath.Rel(cd.basedir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path template gives %q which is outside of %s", buf.String(), cd.basedir)
+	}
+	return path, nil
+}
+
+// term gets the enrollment term for a course. It is only
+// requested when there is a path template.
+func (cd *CourseDownloader) term(course *canvas.Course) Term {
+	if cd.PathTemplate == nil {
+		return Term{}
+	}
+	term, err := CourseTerm(course.ID)
+	if err != nil {
+		log.Printf("could not get term for %s: %v\n", course.Name, err)
+	}
+	return term
+}
+
+// CourseTerm gets the enrollment term of a course.
+func CourseTerm(courseID int) (Term, error) {
+	var c struct {
+		Term Term `json:"term"`
+	}
+	err := rest.Get(rest.Path("courses", courseID), url.Values{"include[]": {"term"}}, &c)
+	return c.Term, err
+}
@@ -0,0 +1,58 @@
+package files
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/harrybrwn/go-canvas"
+)
+
+func TestDestination(t *testing.T) {
+	cd := NewDownloader("/base")
+	course := &canvas.Course{Name: "CSE 100 01", CourseCode: "CSE-100"}
+	pair := &filePathPair{
+		path:   "/base/CSE 100 01/labs/lab1.pdf",
+		folder: "labs",
+		file:   &canvas.File{Filename: "lab1.pdf"},
+	}
+	p, err := cd.destination(course, Term{}, pair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != pair.path {
+		t.Errorf("expected default path without a template, got %q", p)
+	}
+
+	tests := []struct {
+		tmpl, exp string
+	}{
+		{"{{.Course.CourseCode | lower}}/{{.Folder}}/{{.File.Filename}}", "cse-100/labs/lab1.pdf"},
+		{"{{.Term.Name | nospace}}/{{.Course.Name | replace \" \" \"\"}}/{{.File.Filename}}", "Fall_2020/CSE10001/lab1.pdf"},
+	}
+	for _, tt := range tests {
+		cd.PathTemplate, err = ParseTemplate(tt.tmpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err = cd.destination(course, Term{Name: "Fall 2020"}, pair)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := filepath.Join("/base", tt.exp); p != exp {
+			t.Errorf("got %q, want %q", p, exp)
+		}
+	}
+	cd.PathTemplate, err = ParseTemplate("{{.Folder}}/{{.File.Filename}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"../../etc/passwd", "..", "."} {
+		pair.file.Filename = name
+		if p, err = cd.destination(course, Term{}, &filePathPair{folder: "", file: pair.file}); err == nil {
+			t.Errorf("%q: expected an error for a path outside the base directory, got %q", name, p)
+		}
+	}
+	if _, err = ParseTemplate("{{.Course"); err == nil {
+		t.Error("expected a parse error")
+	}
+}
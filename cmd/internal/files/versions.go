@@ -0,0 +1,144 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const versionsDir = "versions"
+
+// Version is a previous copy of a canvas file.
+type Version struct {
+	Path      string    `json:"path"` // relative to the base directory
+	Size      int64     `json:"size"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Checksum  string    `json:"checksum"`
+	SavedAt   time.Time `json:"saved_at"`
+}
+
+// StashVersion moves the current local copy of an entry into the
+// versions directory and records it in the manifest.
+func (m *Manifest) StashVersion(e *Entry) error {
+	current := m.Fullpath(e)
+	stat, err := os.Stat(current)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	sum, err := checksum(current)
+	if err != nil {
+		return err
+	}
+	updated := e.UpdatedAt
+	if updated.IsZero() {
+		updated = stat.ModTime()
+	}
+	v := Version{
+		Size:      stat.Size(),
+		UpdatedAt: updated,
+		Checksum:  sum,
+		SavedAt:   time.Now(),
+	}
+	key := updated.UTC().Format("20060102T150405Z")
+	for i := 1; ; i++ {
+		v.Path = filepath.Join(MetaDir, versionsDir, strconv.Itoa(e.ID), key, filepath.Base(current))
+		if !exists(filepath.Join(m.basedir, v.Path)) {
+			break
+		}
+		if hasVersion(e, v.Path, sum) {
+			// this copy has already been saved
+			return os.Remove(current)
+		}
+		key = fmt.Sprintf("%s-%d", updated.UTC().Format("20060102T150405Z"), i)
+	}
+	dest := filepath.Join(m.basedir, v.Path)
+	if err = mkdir(filepath.Dir(dest)); err != nil {
+		return err
+	}
+	if err = os.Rename(current, dest); err != nil {
+		return err
+	}
+	e.Versions = append(e.Versions, v)
+	m.Set(e)
+	return nil
+}
+
+func hasVersion(e *Entry, path, sum string) bool {
+	for _, v := range e.Versions {
+		if v.Path == path && v.Checksum == sum {
+			return true
+		}
+	}
+	return false
+}
+
+// Find will find the manifest entry for a local file path. The path
+// can be absolute or relative to the base directory.
+func (m *Manifest) Find(path string) (*Entry, error) {
+	rel := path
+	if filepath.IsAbs(path) {
+		var err error
+		if rel, err = filepath.Rel(m.basedir, path); err != nil {
+			return nil, err
+		}
+	}
+	rel = filepath.Clean(rel)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.files {
+		if e.Path == rel {
+			return e.copy(), nil
+		}
+	}
+	return nil, fmt.Errorf("%s is not a downloaded canvas file", path)
+}
+
+// Restore will replace the current copy of a file with one of its
+// versions. The current copy is saved as a version first so
+// nothing is lost.
+func (m *Manifest) Restore(id, version int) error {
+	e := m.Get(id)
+	if e == nil {
+		return errors.New("file is not in the manifest")
+	}
+	if version < 0 || version >= len(e.Versions) {
+		return fmt.Errorf("file has no version %d", version)
+	}
+	v := e.Versions[version]
+	if err := m.StashVersion(e); err != nil {
+		return err
+	}
+	if err := copyFile(filepath.Join(m.basedir, v.Path), m.Fullpath(e)); err != nil {
+		return err
+	}
+	// Keep the canvas update time so the next update does
+	// not overwrite the restored file.
+	e.Checksum = v.Checksum
+	m.Set(e)
+	return nil
+}
+
+func copyFile(src, dst string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := out.Close(); e != nil && err == nil {
+			err = e
+		}
+	}()
+	_, err = io.Copy(out, in)
+	return err
+}
@@ -0,0 +1,190 @@
+// Package filesync keeps a local folder and a folder in
+// the user's canvas files in sync.
+package filesync
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// Op is something that sync will do to a file.
+type Op int
+
+// Sync operations
+const (
+	// Upload sends a local file to canvas.
+	Upload Op = iota
+	// Download gets a canvas file that is not local
+	// or has only changed on canvas.
+	Download
+	// Mkdir creates a folder on canvas.
+	Mkdir
+	// Conflict means that a file changed locally and
+	// on canvas so nothing is done.
+	Conflict
+	// Skip means a change was found but will be left
+	// alone (ex. remote changes without --download).
+	Skip
+)
+
+func (op Op) String() string {
+	switch op {
+	case Upload:
+		return "upload"
+	case Download:
+		return "download"
+	case Mkdir:
+		return "mkdir"
+	case Conflict:
+		return "conflict"
+	case Skip:
+		return "skip"
+	}
+	return fmt.Sprintf("Op(%d)", int(op))
+}
+
+// LocalFile is a file in the local folder.
+type LocalFile struct {
+	Path    string // relative to the local folder, always uses '/'
+	Size    int64
+	ModTime time.Time
+}
+
+// RemoteFile is a file in the canvas folder.
+type RemoteFile struct {
+	ID        int
+	Path      string // relative to the canvas folder
+	Size      int64
+	UpdatedAt time.Time
+	URL       string
+}
+
+// Action is one step in a Plan.
+type Action struct {
+	Op     Op
+	Path   string
+	Reason string
+	Local  *LocalFile
+	Remote *RemoteFile
+}
+
+// Plan is the list of actions needed to sync two folders.
+type Plan []Action
+
+// Count returns the number of actions with an op.
+func (p Plan) Count(op Op) int {
+	n := 0
+	for _, a := range p {
+		if a.Op == op {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteTo writes the plan.
+func (p Plan) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, a := range p {
+		n, err := fmt.Fprintf(w, "%-8s %s (%s)\n", a.Op, a.Path, a.Reason)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Tree is the files and folders on one side of a sync.
+type Tree struct {
+	Dirs   map[string]bool
+	Local  map[string]*LocalFile
+	Remote map[string]*RemoteFile
+}
+
+// MakePlan compares the local files, the remote files, and the state
+// from the last sync. Local changes are uploaded and, if download is
+// true, remote changes are downloaded. Files that changed on both sides
+// since the last sync are conflicts.
+func MakePlan(local, remote *Tree, state *State, download bool) Plan {
+	var plan Plan
+	for dir := range local.Dirs {
+		if !remote.Dirs[dir] {
+			plan = append(plan, Action{Op: Mkdir, Path: dir, Reason: "new folder"})
+		}
+	}
+	paths := make(map[string]bool)
+	for p := range local.Local {
+		paths[p] = true
+	}
+	for p := range remote.Remote {
+		paths[p] = true
+	}
+	for p := range state.Files {
+		if !paths[p] {
+			// gone on both sides
+			delete(state.Files, p)
+		}
+	}
+	for p := range paths {
+		var (
+			l    = local.Local[p]
+			r    = remote.Remote[p]
+			prev = state.Files[p]
+			a    = Action{Path: p, Local: l, Remote: r, Op: -1}
+		)
+		switch {
+		case l != nil && r == nil:
+			if prev != nil && !prev.localChanged(l) {
+				a.Op, a.Reason = Skip, "deleted on canvas"
+			} else {
+				a.Op, a.Reason = Upload, "new file"
+			}
+		case l == nil && r != nil:
+			switch {
+			case prev != nil && !prev.remoteChanged(r):
+				a.Op, a.Reason = Skip, "deleted locally"
+			case download:
+				a.Op, a.Reason = Download, "new on canvas"
+			default:
+				a.Op, a.Reason = Skip, "only on canvas"
+			}
+		case prev == nil:
+			if l.Size != r.Size {
+				a.Op, a.Reason = Conflict, "different files with the same name"
+			} else {
+				// probably the same file, the state
+				// is updated after the sync
+				state.set(p, l, r)
+			}
+		default:
+			lc, rc := prev.localChanged(l), prev.remoteChanged(r)
+			switch {
+			case lc && rc:
+				a.Op, a.Reason = Conflict, "changed locally and on canvas"
+			case lc:
+				a.Op, a.Reason = Upload, "changed locally"
+			case rc && download:
+				a.Op, a.Reason = Download, "changed on canvas"
+			case rc:
+				a.Op, a.Reason = Skip, "changed on canvas"
+			}
+		}
+		if a.Op >= 0 {
+			plan = append(plan, a)
+		}
+	}
+	sort.Slice(plan, func(i, j int) bool {
+		if plan[i].Op == Mkdir && plan[j].Op == Mkdir {
+			return plan[i].Path < plan[j].Path
+		}
+		if plan[i].Op == Mkdir || plan[j].Op == Mkdir {
+			// folders are created first
+			return plan[i].Op == Mkdir
+		}
+		return plan[i].Path < plan[j].Path
+	})
+	return plan
+}
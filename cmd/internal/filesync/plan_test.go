@@ -0,0 +1,155 @@
+package filesync
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMakePlan(t *testing.T) {
+	var (
+		t0 = time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC)
+		t1 = t0.Add(time.Hour)
+	)
+	local := &Tree{
+		Dirs: map[string]bool{"hw": true, "notes": true},
+		Local: map[string]*LocalFile{
+			"new.txt":       {Path: "new.txt", Size: 1, ModTime: t0},
+			"hw/edited.txt": {Path: "hw/edited.txt", Size: 2, ModTime: t1},
+			"both.txt":      {Path: "both.txt", Size: 3, ModTime: t1},
+			"remote.txt":    {Path: "remote.txt", Size: 4, ModTime: t0},
+			"same.txt":      {Path: "same.txt", Size: 5, ModTime: t0},
+			"clash.txt":     {Path: "clash.txt", Size: 6, ModTime: t0},
+		},
+	}
+	remote := &Tree{
+		Dirs: map[string]bool{"hw": true},
+		Remote: map[string]*RemoteFile{
+			"hw/edited.txt": {ID: 2, Path: "hw/edited.txt", Size: 2, UpdatedAt: t0},
+			"both.txt":      {ID: 3, Path: "both.txt", Size: 3, UpdatedAt: t1},
+			"remote.txt":    {ID: 4, Path: "remote.txt", Size: 40, UpdatedAt: t1},
+			"same.txt":      {ID: 5, Path: "same.txt", Size: 5, UpdatedAt: t0},
+			"clash.txt":     {ID: 6, Path: "clash.txt", Size: 60, UpdatedAt: t0},
+			"only.txt":      {ID: 7, Path: "only.txt", Size: 7, UpdatedAt: t0},
+		},
+	}
+	synced := func(id int, size int64) *StateEntry {
+		return &StateEntry{ID: id, LocalSize: size, LocalModTime: t0, RemoteSize: size, RemoteUpdatedAt: t0}
+	}
+	newState := func() *State {
+		return &State{Files: map[string]*StateEntry{
+			"hw/edited.txt": synced(2, 2),
+			"both.txt":      synced(3, 3),
+			"remote.txt":    synced(4, 4),
+			"gone.txt":      synced(8, 8),
+		}}
+	}
+
+	state := newState()
+	plan := MakePlan(local, remote, state, false)
+	expected := []struct {
+		op   Op
+		path string
+	}{
+		{Mkdir, "notes"},
+		{Conflict, "both.txt"},
+		{Conflict, "clash.txt"},
+		{Upload, "hw/edited.txt"},
+		{Upload, "new.txt"},
+		{Skip, "only.txt"},
+		{Skip, "remote.txt"},
+	}
+	if len(plan) != len(expected) {
+		plan.WriteTo(os.Stdout)
+		t.Fatalf("expected %d actions, got %d", len(expected), len(plan))
+	}
+	for i, exp := range expected {
+		if plan[i].Op != exp.op || plan[i].Path != exp.path {
+			t.Errorf("action %d: expected %s %s, got %s %s", i, exp.op, exp.path, plan[i].Op, plan[i].Path)
+		}
+	}
+	if _, ok := state.Files["same.txt"]; !ok {
+		t.Error("identical files should be added to the state")
+	}
+	if _, ok := state.Files["gone.txt"]; ok {
+		t.Error("files missing on both sides should be removed from the state")
+	}
+
+	plan = MakePlan(local, remote, newState(), true)
+	for _, a := range plan {
+		switch a.Path {
+		case "only.txt", "remote.txt":
+			if a.Op != Download {
+				t.Errorf("expected %s to be downloaded, got %s", a.Path, a.Op)
+			}
+		}
+	}
+	if n := plan.Count(Download); n != 2 {
+		t.Errorf("expected 2 downloads, got %d", n)
+	}
+}
+
+func TestMakePlanDeleted(t *testing.T) {
+	t0 := time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC)
+	state := &State{Files: map[string]*StateEntry{
+		"a": {LocalSize: 1, LocalModTime: t0, RemoteSize: 1, RemoteUpdatedAt: t0},
+		"b": {LocalSize: 1, LocalModTime: t0, RemoteSize: 1, RemoteUpdatedAt: t0},
+	}}
+	local := &Tree{Local: map[string]*LocalFile{"a": {Path: "a", Size: 1, ModTime: t0}}}
+	remote := &Tree{Remote: map[string]*RemoteFile{"b": {Path: "b", Size: 1, UpdatedAt: t0}}}
+	for _, a := range MakePlan(local, remote, state, true) {
+		if a.Op != Skip {
+			t.Errorf("deleted file %s should be skipped, got %s", a.Path, a.Op)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "edu-filesync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s, err := ReadState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Files) != 0 {
+		t.Error("expected an empty state")
+	}
+	s.Folder = "/school"
+	now := time.Now().Round(time.Second)
+	s.set("x/y.txt", &LocalFile{Size: 1, ModTime: now}, &RemoteFile{ID: 9, Size: 1, UpdatedAt: now})
+	if err = s.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	s, err = ReadState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := s.Files["x/y.txt"]
+	if s.Folder != "/school" || e == nil || e.ID != 9 || !e.LocalModTime.Equal(now) {
+		t.Errorf("state was not saved: %+v", s)
+	}
+
+	if err = os.MkdirAll(filepath.Join(dir, "sub", ".hidden"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"sub/a.txt", "sub/.hidden/b.txt", ".c"} {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tree, err := ListLocal(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Local) != 1 || tree.Local["sub/a.txt"] == nil {
+		t.Errorf("wrong local files: %v", tree.Local)
+	}
+	if len(tree.Dirs) != 1 || !tree.Dirs["sub"] {
+		t.Errorf("wrong local dirs: %v", tree.Dirs)
+	}
+}
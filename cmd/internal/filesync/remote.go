@@ -0,0 +1,150 @@
+package filesync
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/harrybrwn/edu/cmd/internal/rest"
+)
+
+type folder struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type file struct {
+	ID          int       `json:"id"`
+	DisplayName string    `json:"display_name"`
+	Size        int64     `json:"size"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	URL         string    `json:"url"`
+}
+
+// ListRemote finds all the files and folders under a folder in the
+// user's canvas files. An empty tree is returned if the folder does
+// not exist yet.
+func ListRemote(folderPath string) (*Tree, error) {
+	tree := &Tree{Dirs: make(map[string]bool), Remote: make(map[string]*RemoteFile)}
+	p := "users/self/folders/by_path"
+	if segs := splitPath(folderPath); len(segs) > 0 {
+		parts := make([]interface{}, len(segs))
+		for i, s := range segs {
+			parts[i] = s
+		}
+		p += "/" + rest.Path(parts...)
+	}
+	var folders []folder
+	err := rest.Get(p, nil, &folders)
+	if e, ok := err.(*rest.Error); ok && e.Status == http.StatusNotFound {
+		return tree, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(folders) == 0 {
+		return tree, nil
+	}
+	// by_path returns every folder in the path, the last one is ours
+	return tree, listFolder(tree, folders[len(folders)-1].ID, "")
+}
+
+func listFolder(tree *Tree, id int, prefix string) error {
+	err := rest.Pages(rest.Path("folders", id, "files"), nil, func(page []byte) error {
+		var files []file
+		if err := json.Unmarshal(page, &files); err != nil {
+			return err
+		}
+		for _, f := range files {
+			p := path.Join(prefix, f.DisplayName)
+			tree.Remote[p] = &RemoteFile{
+				ID:        f.ID,
+				Path:      p,
+				Size:      f.Size,
+				UpdatedAt: f.UpdatedAt,
+				URL:       f.URL,
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	var subfolders []folder
+	err = rest.Pages(rest.Path("folders", id, "folders"), nil, func(page []byte) error {
+		var fs []folder
+		if err := json.Unmarshal(page, &fs); err != nil {
+			return err
+		}
+		subfolders = append(subfolders, fs...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, sub := range subfolders {
+		p := path.Join(prefix, sub.Name)
+		tree.Dirs[p] = true
+		if err = listFolder(tree, sub.ID, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mkdir creates a folder in the user's canvas files.
+func mkdir(folderPath string) error {
+	parent, name := path.Split(folderPath)
+	form := url.Values{
+		"name":               {name},
+		"parent_folder_path": {parent},
+	}
+	return rest.Post("users/self/folders", form, nil)
+}
+
+// ListLocal finds all the files and folders in a local directory.
+// Hidden files and folders are ignored.
+func ListLocal(dir string) (*Tree, error) {
+	tree := &Tree{Dirs: make(map[string]bool), Local: make(map[string]*LocalFile)}
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == dir {
+			return nil
+		}
+		if strings.HasPrefix(info.Name(), ".") {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() {
+			tree.Dirs[rel] = true
+		} else if info.Mode().IsRegular() {
+			tree.Local[rel] = &LocalFile{Path: rel, Size: info.Size(), ModTime: info.ModTime()}
+		}
+		return nil
+	})
+	return tree, err
+}
+
+func splitPath(p string) []string {
+	var segs []string
+	for _, s := range strings.Split(p, "/") {
+		if s != "" {
+			segs = append(segs, s)
+		}
+	}
+	return segs
+}
@@ -0,0 +1,80 @@
+package filesync
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StateFile is the name of the file in the local folder
+// that has the state from the last sync.
+const StateFile = ".edu-sync.json"
+
+// State is what each file looked like after the last sync.
+type State struct {
+	// Folder is the canvas folder that was synced.
+	Folder string                 `json:"folder"`
+	Files  map[string]*StateEntry `json:"files"`
+}
+
+// StateEntry is the state of one file.
+type StateEntry struct {
+	ID              int       `json:"id"`
+	LocalSize       int64     `json:"local_size"`
+	LocalModTime    time.Time `json:"local_mod_time"`
+	RemoteSize      int64     `json:"remote_size"`
+	RemoteUpdatedAt time.Time `json:"remote_updated_at"`
+}
+
+func (e *StateEntry) localChanged(l *LocalFile) bool {
+	return e.LocalSize != l.Size || !e.LocalModTime.Equal(l.ModTime)
+}
+
+func (e *StateEntry) remoteChanged(r *RemoteFile) bool {
+	return e.RemoteSize != r.Size || !e.RemoteUpdatedAt.Equal(r.UpdatedAt)
+}
+
+func (s *State) set(path string, l *LocalFile, r *RemoteFile) {
+	s.Files[path] = &StateEntry{
+		ID:              r.ID,
+		LocalSize:       l.Size,
+		LocalModTime:    l.ModTime,
+		RemoteSize:      r.Size,
+		RemoteUpdatedAt: r.UpdatedAt,
+	}
+}
+
+// ReadState reads the state file in a local folder. An empty
+// state is returned if the folder has not been synced.
+func ReadState(dir string) (*State, error) {
+	s := &State{Files: make(map[string]*StateEntry)}
+	b, err := ioutil.ReadFile(filepath.Join(dir, StateFile))
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	if s.Files == nil {
+		s.Files = make(map[string]*StateEntry)
+	}
+	return s, nil
+}
+
+// Save writes the state file.
+func (s *State) Save(dir string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(dir, StateFile+".tmp")
+	if err = ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, StateFile))
+}
@@ -0,0 +1,130 @@
+package filesync
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/harrybrwn/go-canvas"
+)
+
+// Syncer syncs a local directory with a folder in
+// the user's canvas files.
+type Syncer struct {
+	// Dir is the local directory.
+	Dir string
+	// Folder is the canvas folder path.
+	Folder string
+	// Download will download files that are new or
+	// changed on canvas.
+	Download bool
+	// Stdout is where each action is printed.
+	Stdout io.Writer
+}
+
+// Plan compares the local directory with the canvas folder.
+func (s *Syncer) Plan() (Plan, *State, error) {
+	state, err := ReadState(s.Dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	folder := "/" + path.Join(splitPath(s.Folder)...)
+	if state.Folder != folder {
+		// the state is for some other folder
+		state = &State{Folder: folder, Files: make(map[string]*StateEntry)}
+	}
+	local, err := ListLocal(s.Dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	remote, err := ListRemote(folder)
+	if err != nil {
+		return nil, nil, err
+	}
+	return MakePlan(local, remote, state, s.Download), state, nil
+}
+
+// Apply carries out a plan and saves the new state. Failed actions are
+// printed and the rest of the plan is still run.
+func (s *Syncer) Apply(plan Plan, state *State) (failed int, err error) {
+	for _, a := range plan {
+		var err error
+		switch a.Op {
+		case Mkdir:
+			err = mkdir(path.Join(state.Folder, a.Path))
+		case Upload:
+			err = s.upload(a, state)
+		case Download:
+			err = s.download(a, state)
+		default:
+			continue
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(s.Stdout, "failed   %s: %v\n", a.Path, err)
+			continue
+		}
+		fmt.Fprintf(s.Stdout, "%-8s %s\n", a.Op, a.Path)
+	}
+	return failed, state.Save(s.Dir)
+}
+
+func (s *Syncer) upload(a Action, state *State) (err error) {
+	f, err := os.Open(filepath.Join(s.Dir, filepath.FromSlash(a.Path)))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := f.Close(); e != nil && err == nil {
+			err = e
+		}
+	}()
+	dir, name := path.Split(path.Join(state.Folder, a.Path))
+	file, err := canvas.UploadFile(name, f,
+		canvas.Opt("parent_folder_path", dir),
+		canvas.Opt("on_duplicate", "overwrite"),
+	)
+	if err != nil {
+		return err
+	}
+	state.set(a.Path, a.Local, &RemoteFile{
+		ID:        file.ID,
+		Size:      int64(file.Size),
+		UpdatedAt: file.UpdatedAt,
+	})
+	return nil
+}
+
+func (s *Syncer) download(a Action, state *State) (err error) {
+	if a.Remote.URL == "" {
+		return fmt.Errorf("no download url (the file may be locked)")
+	}
+	dest := filepath.Join(s.Dir, filepath.FromSlash(a.Path))
+	if err = os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(dest), ".edu-sync-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = (&canvas.File{URL: a.Remote.URL}).WriteTo(tmp)
+	if e := tmp.Close(); e != nil && err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), dest); err != nil {
+		return err
+	}
+	stat, err := os.Stat(dest)
+	if err != nil {
+		return err
+	}
+	state.set(a.Path, &LocalFile{Path: a.Path, Size: stat.Size(), ModTime: stat.ModTime()}, a.Remote)
+	return nil
+}
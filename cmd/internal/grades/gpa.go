@@ -0,0 +1,173 @@
+package grades
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CourseGrade is the grade for one course that counts towards a GPA.
+type CourseGrade struct {
+	Code      string
+	Name      string
+	Term      string
+	TermStart time.Time
+	// Percent and Letter are the grade, a letter in the
+	// scale is used before the percent.
+	Percent *float64
+	Letter  string
+	Units   float64
+	// Projected is true for grades from a scenario.
+	Projected bool
+}
+
+// GPA is the grade point average of a term or of every term.
+type GPA struct {
+	Term    string
+	Courses int
+	Units   float64
+	Points  float64
+}
+
+// Value returns the GPA, zero if there are no units.
+func (g GPA) Value() float64 {
+	if g.Units == 0 {
+		return 0
+	}
+	return g.Points / g.Units
+}
+
+// ProjectedTerm is the term used for scenario courses
+// that are not in canvas.
+const ProjectedTerm = "projected"
+
+// Resolve finds the letter grade and grade points of a course. The bool
+// is false if the course has no grade or the letter is not in the scale.
+func (s Scale) Resolve(c *CourseGrade) (string, float64, bool) {
+	if c.Letter != "" {
+		if pts, ok := s.Points(c.Letter); ok {
+			return c.Letter, pts, true
+		}
+	}
+	if c.Percent == nil {
+		return "", 0, false
+	}
+	letter := s.Letter(*c.Percent)
+	pts, ok := s.Points(letter)
+	return letter, pts, ok
+}
+
+// Compute finds the GPA of each term, sorted by the term start date,
+// and the cumulative GPA. Courses without a grade or units are skipped.
+func (s Scale) Compute(courses []CourseGrade) ([]GPA, GPA) {
+	var (
+		total = GPA{Term: "cumulative"}
+		terms = make(map[string]*GPA)
+		start = make(map[string]time.Time)
+		names []string
+	)
+	for i := range courses {
+		c := &courses[i]
+		_, pts, ok := s.Resolve(c)
+		if !ok || c.Units <= 0 {
+			continue
+		}
+		t, ok := terms[c.Term]
+		if !ok {
+			t = &GPA{Term: c.Term}
+			terms[c.Term] = t
+			start[c.Term] = c.TermStart
+			names = append(names, c.Term)
+		}
+		for _, g := range []*GPA{t, &total} {
+			g.Courses++
+			g.Units += c.Units
+			g.Points += pts * c.Units
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		a, b := names[i], names[j]
+		// projected grades come after the real ones
+		if (a == ProjectedTerm) != (b == ProjectedTerm) {
+			return b == ProjectedTerm
+		}
+		return start[a].Before(start[b])
+	})
+	gpas := make([]GPA, len(names))
+	for i, name := range names {
+		gpas[i] = *terms[name]
+	}
+	return gpas, total
+}
+
+// Units returns the units for a course code. The codes are
+// compared without case and the default is used if the code
+// is not in the map.
+func Units(units map[string]float64, code string, def float64) float64 {
+	for c, u := range units {
+		if strings.EqualFold(c, code) {
+			return u
+		}
+	}
+	return def
+}
+
+// Scenario changes the grades of courses or adds projected courses.
+// Each scenario is written as "<course>=<grade>[,<units>]" where the
+// course is a course code or name and the grade is a letter or percent
+// (ex. "CSE 100=A-" or "MATH 24=88%,4"). Courses that are not found are
+// added to the projected term with the default units.
+func (s Scale) Scenario(courses []CourseGrade, specs []string, def float64) ([]CourseGrade, error) {
+	for _, spec := range specs {
+		i := strings.LastIndex(spec, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("bad scenario %q: expected <course>=<grade>", spec)
+		}
+		name, grade := strings.TrimSpace(spec[:i]), strings.TrimSpace(spec[i+1:])
+		units := -1.0
+		if j := strings.Index(grade, ","); j >= 0 {
+			u, err := strconv.ParseFloat(strings.TrimSpace(grade[j+1:]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("bad units in scenario %q: %v", spec, err)
+			}
+			units = u
+			grade = strings.TrimSpace(grade[:j])
+		}
+		if grade == "" {
+			return nil, fmt.Errorf("bad scenario %q: no grade", spec)
+		}
+		c := CourseGrade{Code: name, Name: name, Term: ProjectedTerm, Units: def}
+		idx := -1
+		for k := range courses {
+			if strings.EqualFold(courses[k].Code, name) || strings.EqualFold(courses[k].Name, name) {
+				idx = k
+				c = courses[k]
+				break
+			}
+		}
+		c.Projected = true
+		c.Letter, c.Percent = "", nil
+		if strings.HasSuffix(grade, "%") {
+			p, err := strconv.ParseFloat(strings.TrimSuffix(grade, "%"), 64)
+			if err != nil {
+				return nil, fmt.Errorf("bad grade in scenario %q: %v", spec, err)
+			}
+			c.Percent = &p
+		} else if _, ok := s.Points(grade); ok {
+			c.Letter = grade
+		} else {
+			return nil, fmt.Errorf("bad scenario %q: %s is not in the grade scale", spec, grade)
+		}
+		if units >= 0 {
+			c.Units = units
+		}
+		if idx >= 0 {
+			courses[idx] = c
+		} else {
+			courses = append(courses, c)
+		}
+	}
+	return courses, nil
+}
@@ -0,0 +1,77 @@
+package grades
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGPA(t *testing.T) {
+	var (
+		fall   = time.Date(2019, 8, 20, 0, 0, 0, 0, time.UTC)
+		spring = time.Date(2020, 1, 15, 0, 0, 0, 0, time.UTC)
+		pct    = func(f float64) *float64 { return &f }
+	)
+	courses := []CourseGrade{
+		{Code: "MATH 21", Term: "Spring 2020", TermStart: spring, Letter: "B", Units: 4},
+		{Code: "CSE 15", Term: "Fall 2019", TermStart: fall, Percent: pct(95), Units: 4},
+		{Code: "WRI 10", Term: "Fall 2019", TermStart: fall, Letter: "B+", Percent: pct(50), Units: 2},
+		{Code: "PE 1", Term: "Fall 2019", TermStart: fall, Letter: "P", Units: 1},
+		{Code: "SEM 1", Term: "Spring 2020", TermStart: spring, Letter: "A", Units: 0},
+		{Code: "CSE 30", Term: "Spring 2020", TermStart: spring},
+	}
+	terms, total := DefaultScale.Compute(courses)
+	if len(terms) != 2 || terms[0].Term != "Fall 2019" || terms[1].Term != "Spring 2020" {
+		t.Fatalf("wrong terms: %+v", terms)
+	}
+	// (4*4 + 3.3*2) / 6
+	if g := terms[0].Value(); math.Abs(g-22.6/6) > 1e-9 || terms[0].Courses != 2 {
+		t.Errorf("wrong fall gpa: %v", g)
+	}
+	if g := terms[1].Value(); g != 3 {
+		t.Errorf("wrong spring gpa: %v", g)
+	}
+	if g := total.Value(); math.Abs(g-34.6/10) > 1e-9 || total.Units != 10 {
+		t.Errorf("wrong cumulative gpa: %v", g)
+	}
+
+	courses, err := DefaultScale.Scenario(courses, []string{"math 21=A", "CSE 100=85%,4", "CSE 30 = C,2"}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(courses) != 7 || !courses[0].Projected || courses[0].Letter != "A" || courses[0].Units != 4 {
+		t.Errorf("wrong scenario courses: %+v", courses)
+	}
+	terms, total = DefaultScale.Compute(courses)
+	if len(terms) != 3 || terms[2].Term != ProjectedTerm || terms[2].Units != 4 {
+		t.Errorf("wrong projected term: %+v", terms)
+	}
+	// 22.6 + 4*4 + 2*2 + 3*4
+	if g := total.Value(); math.Abs(g-54.6/16) > 1e-9 {
+		t.Errorf("wrong projected gpa: %v", g)
+	}
+	for _, bad := range []string{"CSE 15", "CSE 15=Z", "CSE 15=A,x", "CSE 15=x%"} {
+		if _, err = DefaultScale.Scenario(nil, []string{bad}, 3); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestUnits(t *testing.T) {
+	units := map[string]float64{"CSE 15": 4, "pe 1": 1}
+	if u := Units(units, "cse 15", 3); u != 4 {
+		t.Errorf("got %v", u)
+	}
+	if u := Units(units, "PE 1", 3); u != 1 {
+		t.Errorf("got %v", u)
+	}
+	if u := Units(units, "MATH 21", 3); u != 3 {
+		t.Errorf("got %v", u)
+	}
+	if err := DefaultScale.Validate(); err != nil {
+		t.Error(err)
+	}
+	if err := (Scale{{"A", 90, 4}, {"B", 95, 3}}).Validate(); err == nil {
+		t.Error("expected an error for an unsorted scale")
+	}
+}
@@ -0,0 +1,270 @@
+package grades
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/harrybrwn/edu/cmd/internal/rest"
+)
+
+// Enrollment is the user's enrollment in a course with their grades.
+type Enrollment struct {
+	CourseID int    `json:"course_id"`
+	Type     string `json:"type"`
+	Grades   struct {
+		CurrentScore *float64 `json:"current_score"`
+		FinalScore   *float64 `json:"final_score"`
+		CurrentGrade string   `json:"current_grade"`
+		FinalGrade   string   `json:"final_grade"`
+	} `json:"grades"`
+}
+
+// Enrollments gets the user's active student enrollments. Completed
+// enrollments are included if all is true.
+func Enrollments(all bool) ([]Enrollment, error) {
+	params := url.Values{
+		"type[]":  {"StudentEnrollment"},
+		"state[]": {"active"},
+	}
+	if all {
+		params["state[]"] = append(params["state[]"], "completed")
+	}
+	var enrollments []Enrollment
+	err := rest.Pages("users/self/enrollments", params, func(page []byte) error {
+		var e []Enrollment
+		if err := json.Unmarshal(page, &e); err != nil {
+			return err
+		}
+		enrollments = append(enrollments, e...)
+		return nil
+	})
+	return enrollments, err
+}
+
+// Group is an assignment group.
+type Group struct {
+	ID     int     `json:"id"`
+	Name   string  `json:"name"`
+	Weight float64 `json:"group_weight"`
+	Rules  struct {
+		DropLowest  int   `json:"drop_lowest"`
+		DropHighest int   `json:"drop_highest"`
+		NeverDrop   []int `json:"never_drop"`
+	} `json:"rules"`
+	Assignments []*Assignment `json:"assignments"`
+}
+
+// Groups gets the assignment groups of a course with their assignments and
+// the user's submissions. The bool is true if the course uses group weights.
+func Groups(courseID int) ([]*Group, bool, error) {
+	var course struct {
+		Weighted bool `json:"apply_assignment_group_weights"`
+	}
+	if err := rest.Get(rest.Path("courses", courseID), nil, &course); err != nil {
+		return nil, false, err
+	}
+	params := url.Values{"include[]": {"assignments", "submission"}}
+	var groups []*Group
+	err := rest.Pages(rest.Path("courses", courseID, "assignment_groups"), params, func(page []byte) error {
+		var g []*Group
+		if err := json.Unmarshal(page, &g); err != nil {
+			return err
+		}
+		groups = append(groups, g...)
+		return nil
+	})
+	return groups, course.Weighted, err
+}
+
+// GroupResult is the grade for one assignment group.
+type GroupResult struct {
+	Group    *Group
+	Earned   float64
+	Possible float64
+	// Percent is nil if nothing in the group has been graded.
+	Percent *float64
+	// Dropped are the assignments dropped by the group rules.
+	Dropped []*Assignment
+}
+
+// Result is a course grade.
+type Result struct {
+	Groups   []GroupResult
+	Weighted bool
+	// Percent is nil if nothing has been graded.
+	Percent *float64
+}
+
+type graded struct {
+	as       *Assignment
+	earned   float64
+	possible float64
+}
+
+// Compute calculates the current grade (ungraded work is not counted) the
+// same way canvas does. The what-if scores replace the real score of an
+// assignment and are keyed by assignment id.
+func Compute(groups []*Group, weighted bool, whatIf map[int]float64) *Result {
+	res := &Result{Weighted: weighted}
+	var earned, possible, weights, weightedSum float64
+	for _, g := range groups {
+		gr := GroupResult{Group: g}
+		var scores []graded
+		for _, as := range g.Assignments {
+			if as.OmitFromFinalGrade {
+				continue
+			}
+			score, ok := whatIf[as.ID]
+			if !ok {
+				sub := as.Submission
+				if sub == nil || sub.Score == nil || sub.Excused {
+					continue
+				}
+				score = *sub.Score
+			}
+			scores = append(scores, graded{as: as, earned: score, possible: as.PointsPossible})
+		}
+		scores, gr.Dropped = drop(scores, g)
+		for _, s := range scores {
+			gr.Earned += s.earned
+			gr.Possible += s.possible
+		}
+		if len(scores) > 0 && gr.Possible > 0 {
+			p := gr.Earned / gr.Possible * 100
+			gr.Percent = &p
+			weights += g.Weight
+			weightedSum += g.Weight * p
+		}
+		earned += gr.Earned
+		possible += gr.Possible
+		res.Groups = append(res.Groups, gr)
+	}
+	switch {
+	case weighted && weights > 0:
+		// groups with nothing graded don't count so
+		// the weights that are left are scaled up
+		p := weightedSum / weights
+		res.Percent = &p
+	case !weighted && possible > 0:
+		p := earned / possible * 100
+		res.Percent = &p
+	}
+	return res
+}
+
+// drop removes the lowest and highest scores from a group
+// following the group's rules.
+func drop(scores []graded, g *Group) (kept []graded, dropped []*Assignment) {
+	lowest, highest := g.Rules.DropLowest, g.Rules.DropHighest
+	if lowest == 0 && highest == 0 {
+		return scores, nil
+	}
+	never := make(map[int]bool)
+	for _, id := range g.Rules.NeverDrop {
+		never[id] = true
+	}
+	var candidates []graded
+	for _, s := range scores {
+		if never[s.as.ID] {
+			kept = append(kept, s)
+		} else {
+			candidates = append(candidates, s)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return percent(candidates[i]) < percent(candidates[j])
+	})
+	// always keep at least one score
+	for lowest > 0 && len(candidates) > 1 {
+		dropped = append(dropped, candidates[0].as)
+		candidates = candidates[1:]
+		lowest--
+	}
+	for highest > 0 && len(candidates) > 1 {
+		dropped = append(dropped, candidates[len(candidates)-1].as)
+		candidates = candidates[:len(candidates)-1]
+		highest--
+	}
+	return append(kept, candidates...), dropped
+}
+
+func percent(s graded) float64 {
+	if s.possible == 0 {
+		return 0
+	}
+	return s.earned / s.possible
+}
+
+// WhatIf parses hypothetical scores written as "<assignment>=<score>". The
+// assignment can be an id or a name (or the start of one) and the score can
+// be points or a percent (ex. "hw 3=9.5" or "Final Exam=85%").
+func WhatIf(specs []string, groups []*Group) (map[int]float64, error) {
+	scores := make(map[int]float64)
+	for _, spec := range specs {
+		i := strings.LastIndex(spec, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("bad what-if score %q: expected <assignment>=<score>", spec)
+		}
+		name, score := strings.TrimSpace(spec[:i]), strings.TrimSpace(spec[i+1:])
+		as, err := findAssignment(groups, name)
+		if err != nil {
+			return nil, err
+		}
+		isPercent := strings.HasSuffix(score, "%")
+		f, err := strconv.ParseFloat(strings.TrimSuffix(score, "%"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad what-if score %q: %v", spec, err)
+		}
+		if isPercent {
+			f = f / 100 * as.PointsPossible
+		}
+		scores[as.ID] = f
+	}
+	return scores, nil
+}
+
+// ReadWhatIf reads what-if scores from a file with one score on each
+// line. Empty lines and lines starting with '#' are skipped.
+func ReadWhatIf(r io.Reader) ([]string, error) {
+	var specs []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		specs = append(specs, line)
+	}
+	return specs, sc.Err()
+}
+
+func findAssignment(groups []*Group, name string) (*Assignment, error) {
+	id, err := strconv.Atoi(name)
+	if err != nil {
+		id = -1
+	}
+	var matches []*Assignment
+	for _, g := range groups {
+		for _, as := range g.Assignments {
+			switch {
+			case as.ID == id, strings.EqualFold(as.Name, name):
+				return as, nil
+			case strings.HasPrefix(strings.ToLower(as.Name), strings.ToLower(name)):
+				matches = append(matches, as)
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("could not find assignment %q", name)
+	case 1:
+		return matches[0], nil
+	}
+	return nil, fmt.Errorf("%q matches %d assignments", name, len(matches))
+}
@@ -0,0 +1,107 @@
+package grades
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func scored(id int, name string, score, possible float64) *Assignment {
+	return &Assignment{ID: id, Name: name, PointsPossible: possible, Submission: &Submission{Score: &score}}
+}
+
+func ungraded(id int, name string, possible float64) *Assignment {
+	return &Assignment{ID: id, Name: name, PointsPossible: possible, Submission: &Submission{}}
+}
+
+func testGroups() []*Group {
+	hw := &Group{ID: 1, Name: "Homework", Weight: 40, Assignments: []*Assignment{
+		scored(1, "hw 1", 8, 10),
+		scored(2, "hw 2", 10, 10),
+		scored(3, "hw 3", 2, 10),
+	}}
+	hw.Rules.DropLowest = 1
+	exams := &Group{ID: 2, Name: "Exams", Weight: 60, Assignments: []*Assignment{
+		scored(4, "Midterm", 70, 100),
+		ungraded(5, "Final Exam", 100),
+	}}
+	empty := &Group{ID: 3, Name: "Extra", Weight: 0}
+	return []*Group{hw, exams, empty}
+}
+
+func near(a *float64, b float64) bool {
+	return a != nil && math.Abs(*a-b) < 1e-9
+}
+
+func TestCompute(t *testing.T) {
+	groups := testGroups()
+	res := Compute(groups, true, nil)
+	if len(res.Groups) != 3 {
+		t.Fatalf("expected 3 groups, got %d", len(res.Groups))
+	}
+	hw := res.Groups[0]
+	if !near(hw.Percent, 90) || len(hw.Dropped) != 1 || hw.Dropped[0].ID != 3 {
+		t.Errorf("wrong homework result: %+v", hw)
+	}
+	if !near(res.Groups[1].Percent, 70) {
+		t.Errorf("wrong exam percent: %v", res.Groups[1].Percent)
+	}
+	if res.Groups[2].Percent != nil {
+		t.Error("empty group should not have a percent")
+	}
+	// 0.4*90 + 0.6*70
+	if !near(res.Percent, 78) {
+		t.Errorf("wrong weighted percent: %v", *res.Percent)
+	}
+
+	res = Compute(groups, false, nil)
+	// (18 + 70) / (20 + 100)
+	if !near(res.Percent, 88.0/120*100) {
+		t.Errorf("wrong unweighted percent: %v", *res.Percent)
+	}
+
+	res = Compute(groups, true, map[int]float64{5: 90})
+	// 0.4*90 + 0.6*80
+	if !near(res.Percent, 84) {
+		t.Errorf("wrong what-if percent: %v", *res.Percent)
+	}
+
+	res = Compute([]*Group{{Weight: 100, Assignments: []*Assignment{ungraded(1, "a", 10)}}}, true, nil)
+	if res.Percent != nil {
+		t.Error("nothing graded should have no percent")
+	}
+}
+
+func TestWhatIf(t *testing.T) {
+	groups := testGroups()
+	scores, err := WhatIf([]string{"final=85%", "hw 3 = 7", "4=80"}, groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scores[5] != 85 || scores[3] != 7 || scores[4] != 80 {
+		t.Errorf("wrong scores: %v", scores)
+	}
+	for _, bad := range []string{"hw=1", "nothing=1", "final", "final=x"} {
+		if _, err = WhatIf([]string{bad}, groups); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+	specs, err := ReadWhatIf(strings.NewReader("# comment\n\nfinal = 90%\nhw 1=10\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) != 2 || specs[0] != "final = 90%" {
+		t.Errorf("wrong specs: %q", specs)
+	}
+}
+
+func TestScale(t *testing.T) {
+	for p, exp := range map[float64]string{100: "A", 93: "A", 92.9: "A-", 85: "B", 61: "D-", 12: "F"} {
+		if l := DefaultScale.Letter(p); l != exp {
+			t.Errorf("wrong letter for %v: got %s; want %s", p, l, exp)
+		}
+	}
+	if l := (Scale{}).Letter(50); l != "" {
+		t.Errorf("empty scale should have no letter, got %q", l)
+	}
+}
@@ -0,0 +1,62 @@
+package grades
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Cutoff is the lowest percent that gets a letter grade
+// and the grade points that the letter is worth.
+type Cutoff struct {
+	Letter string  `yaml:"letter"`
+	Min    float64 `yaml:"min"`
+	Points float64 `yaml:"points"`
+}
+
+// Scale is a letter grade scale sorted from the highest cutoff
+// to the lowest.
+type Scale []Cutoff
+
+// DefaultScale is the usual plus/minus letter grade scale.
+var DefaultScale = Scale{
+	{"A", 93, 4}, {"A-", 90, 3.7},
+	{"B+", 87, 3.3}, {"B", 83, 3}, {"B-", 80, 2.7},
+	{"C+", 77, 2.3}, {"C", 73, 2}, {"C-", 70, 1.7},
+	{"D+", 67, 1.3}, {"D", 63, 1}, {"D-", 60, 0.7},
+	{"F", 0, 0},
+}
+
+// Letter returns the letter grade for a percent.
+func (s Scale) Letter(percent float64) string {
+	for _, c := range s {
+		if percent >= c.Min {
+			return c.Letter
+		}
+	}
+	if len(s) == 0 {
+		return ""
+	}
+	return s[len(s)-1].Letter
+}
+
+// Points returns the grade points for a letter.
+func (s Scale) Points(letter string) (float64, bool) {
+	for _, c := range s {
+		if strings.EqualFold(c.Letter, letter) {
+			return c.Points, true
+		}
+	}
+	return 0, false
+}
+
+// Validate checks that the scale is sorted from the
+// highest cutoff to the lowest.
+func (s Scale) Validate() error {
+	for i := 1; i < len(s); i++ {
+		if s[i].Min >= s[i-1].Min {
+			return fmt.Errorf("grade scale: %s (%g) must be lower than %s (%g)",
+				s[i].Letter, s[i].Min, s[i-1].Letter, s[i-1].Min)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+// Package grades gets submissions and grades from canvas
+// and does the grade calculations that canvas does not.
+package grades
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/harrybrwn/edu/cmd/internal/rest"
+)
+
+// Assignment is a graded assignment.
+type Assignment struct {
+	ID                 int         `json:"id"`
+	CourseID           int         `json:"course_id"`
+	Name               string      `json:"name"`
+	PointsPossible     float64     `json:"points_possible"`
+	DueAt              *time.Time  `json:"due_at"`
+	GradingType        string      `json:"grading_type"`
+	AssignmentGroupID  int         `json:"assignment_group_id"`
+	OmitFromFinalGrade bool        `json:"omit_from_final_grade"`
+	Rubric             []Criterion `json:"rubric"`
+	Submission         *Submission `json:"submission"`
+}
+
+// Criterion is one row of a rubric.
+type Criterion struct {
+	ID              string   `json:"id"`
+	Description     string   `json:"description"`
+	LongDescription string   `json:"long_description"`
+	Points          float64  `json:"points"`
+	Ratings         []Rating `json:"ratings"`
+}
+
+// Rating is one of the possible ratings for a rubric criterion.
+type Rating struct {
+	ID          string  `json:"id"`
+	Description string  `json:"description"`
+	Points      float64 `json:"points"`
+}
+
+// Submission is the user's submission for an assignment.
+type Submission struct {
+	ID               int                   `json:"id"`
+	AssignmentID     int                   `json:"assignment_id"`
+	Attempt          int                   `json:"attempt"`
+	Score            *float64              `json:"score"`
+	Grade            string                `json:"grade"`
+	SubmittedAt      *time.Time            `json:"submitted_at"`
+	GradedAt         *time.Time            `json:"graded_at"`
+	WorkflowState    string                `json:"workflow_state"`
+	Late             bool                  `json:"late"`
+	Missing          bool                  `json:"missing"`
+	Excused          bool                  `json:"excused"`
+	SecondsLate      int64                 `json:"seconds_late"`
+	Assignment       *Assignment           `json:"assignment"`
+	Comments         []Comment             `json:"submission_comments"`
+	RubricAssessment map[string]Assessment `json:"rubric_assessment"`
+	History          []Submission          `json:"submission_history"`
+}
+
+// Comment is a comment on a submission.
+type Comment struct {
+	AuthorName string    `json:"author_name"`
+	Comment    string    `json:"comment"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Assessment is the grader's rating for one rubric criterion.
+type Assessment struct {
+	Points   *float64 `json:"points"`
+	RatingID string   `json:"rating_id"`
+	Comments string   `json:"comments"`
+}
+
+// Status is a short description of the state of a submission.
+func (s *Submission) Status() string {
+	switch {
+	case s.Excused:
+		return "excused"
+	case s.Missing:
+		return "missing"
+	case s.Late:
+		return "late"
+	case s.WorkflowState == "graded":
+		return "graded"
+	case s.SubmittedAt != nil:
+		return "submitted"
+	}
+	return "unsubmitted"
+}
+
+// Submissions gets the user's submissions for every assignment in a
+// course. They are sorted by due date with undated assignments last.
+func Submissions(courseID int) ([]*Submission, error) {
+	params := url.Values{
+		"student_ids[]": {"self"},
+		"include[]":     {"assignment", "submission_comments", "rubric_assessment"},
+	}
+	var subs []*Submission
+	err := rest.Pages(rest.Path("courses", courseID, "students", "submissions"), params, func(page []byte) error {
+		var s []*Submission
+		if err := json.Unmarshal(page, &s); err != nil {
+			return err
+		}
+		subs = append(subs, s...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(subs, func(i, j int) bool {
+		return dueBefore(subs[i].Assignment, subs[j].Assignment)
+	})
+	return subs, nil
+}
+
+// GetSubmission gets an assignment with its rubric and the user's
+// submission with every attempt.
+func GetSubmission(courseID, assignmentID int) (*Assignment, error) {
+	var as Assignment
+	err := rest.Get(rest.Path("courses", courseID, "assignments", assignmentID), nil, &as)
+	if err != nil {
+		return nil, err
+	}
+	var sub Submission
+	params := url.Values{"include[]": {"submission_comments", "rubric_assessment", "submission_history"}}
+	err = rest.Get(rest.Path("courses", courseID, "assignments", assignmentID, "submissions", "self"), params, &sub)
+	if err != nil {
+		return nil, err
+	}
+	as.Submission = &sub
+	return &as, nil
+}
+
+func dueBefore(a, b *Assignment) bool {
+	switch {
+	case a == nil || a.DueAt == nil:
+		return false
+	case b == nil || b.DueAt == nil:
+		return true
+	}
+	return a.DueAt.Before(*b.DueAt)
+}
+
+// Score formats a score out of the points possible (ex. "8.5/10").
+func Score(score *float64, possible float64) string {
+	s := "-"
+	if score != nil {
+		s = formatFloat(*score)
+	}
+	return fmt.Sprintf("%s/%s", s, formatFloat(possible))
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// RubricRow is a rubric criterion with the grader's assessment.
+type RubricRow struct {
+	Criterion string
+	Rating    string
+	Points    *float64
+	Possible  float64
+	Comments  string
+}
+
+// Rubric matches up the rubric criteria with their assessments.
+func Rubric(criteria []Criterion, assessments map[string]Assessment) []RubricRow {
+	rows := make([]RubricRow, 0, len(criteria))
+	for _, c := range criteria {
+		row := RubricRow{Criterion: c.Description, Possible: c.Points}
+		if a, ok := assessments[c.ID]; ok {
+			row.Points = a.Points
+			row.Comments = a.Comments
+			for _, r := range c.Ratings {
+				if r.ID == a.RatingID {
+					row.Rating = r.Description
+					break
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
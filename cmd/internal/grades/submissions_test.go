@@ -0,0 +1,54 @@
+package grades
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatus(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		s   Submission
+		exp string
+	}{
+		{Submission{}, "unsubmitted"},
+		{Submission{SubmittedAt: &now}, "submitted"},
+		{Submission{SubmittedAt: &now, WorkflowState: "graded"}, "graded"},
+		{Submission{SubmittedAt: &now, Late: true, WorkflowState: "graded"}, "late"},
+		{Submission{Missing: true}, "missing"},
+		{Submission{Missing: true, Excused: true}, "excused"},
+	}
+	for _, tst := range tests {
+		if res := tst.s.Status(); res != tst.exp {
+			t.Errorf("got status %q; want %q", res, tst.exp)
+		}
+	}
+}
+
+func TestScore(t *testing.T) {
+	score := 8.5
+	if s := Score(&score, 10); s != "8.5/10" {
+		t.Errorf("got %q", s)
+	}
+	if s := Score(nil, 2.25); s != "-/2.25" {
+		t.Errorf("got %q", s)
+	}
+}
+
+func TestRubric(t *testing.T) {
+	pts := 3.0
+	criteria := []Criterion{
+		{ID: "a", Description: "style", Points: 5, Ratings: []Rating{{ID: "r1", Description: "ok", Points: 3}, {ID: "r2", Description: "great", Points: 5}}},
+		{ID: "b", Description: "tests", Points: 5},
+	}
+	rows := Rubric(criteria, map[string]Assessment{"a": {Points: &pts, RatingID: "r1", Comments: "indent"}})
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(rows))
+	}
+	if r := rows[0]; r.Rating != "ok" || r.Points == nil || *r.Points != 3 || r.Comments != "indent" || r.Possible != 5 {
+		t.Errorf("wrong row: %+v", r)
+	}
+	if r := rows[1]; r.Points != nil || r.Rating != "" {
+		t.Errorf("expected no assessment: %+v", r)
+	}
+}
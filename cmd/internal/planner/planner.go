@@ -0,0 +1,351 @@
+// Package planner uses the canvas planner api which has everything
+// on the user's to-do list: assignments, quizzes, graded discussions
+// and personal planner notes.
+package planner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harrybrwn/edu/cmd/internal/rest"
+	"github.com/harrybrwn/edu/pkg/term"
+)
+
+// Plannable types
+const (
+	TypeAssignment = "assignment"
+	TypeQuiz       = "quiz"
+	TypeDiscussion = "discussion_topic"
+	TypeNote       = "planner_note"
+)
+
+// Item is an item on the user's planner.
+type Item struct {
+	CourseID      int             `json:"course_id"`
+	ContextName   string          `json:"context_name"`
+	PlannableID   int             `json:"plannable_id"`
+	PlannableType string          `json:"plannable_type"`
+	PlannableDate time.Time       `json:"plannable_date"`
+	HTMLURL       string          `json:"html_url"`
+	Plannable     Plannable       `json:"plannable"`
+	Override      *Override       `json:"planner_override"`
+	RawSubmission json.RawMessage `json:"submissions"`
+
+	submission *Submission
+}
+
+// Plannable is the thing that an item is for.
+type Plannable struct {
+	ID             int        `json:"id"`
+	Title          string     `json:"title"`
+	DueAt          *time.Time `json:"due_at"`
+	TodoDate       *time.Time `json:"todo_date"`
+	PointsPossible float64    `json:"points_possible"`
+	Details        string     `json:"details"`
+}
+
+// Override is the user's override of an item's to-do state.
+type Override struct {
+	ID             int    `json:"id"`
+	PlannableType  string `json:"plannable_type"`
+	PlannableID    int    `json:"plannable_id"`
+	MarkedComplete bool   `json:"marked_complete"`
+	Dismissed      bool   `json:"dismissed"`
+}
+
+// Submission is the submission state of an item.
+type Submission struct {
+	Submitted    bool `json:"submitted"`
+	Excused      bool `json:"excused"`
+	Graded       bool `json:"graded"`
+	Late         bool `json:"late"`
+	Missing      bool `json:"missing"`
+	NeedsGrading bool `json:"needs_grading"`
+	HasFeedback  bool `json:"has_feedback"`
+}
+
+// Submission returns the submission state of the item, nil
+// if the item can't be submitted (canvas sends false).
+func (it *Item) Submission() *Submission {
+	if it.submission == nil && len(it.RawSubmission) > 0 && it.RawSubmission[0] == '{' {
+		var s Submission
+		if json.Unmarshal(it.RawSubmission, &s) == nil {
+			it.submission = &s
+		}
+	}
+	return it.submission
+}
+
+// Type is a short name for the type of the item.
+func (it *Item) Type() string {
+	switch it.PlannableType {
+	case TypeDiscussion:
+		return "discussion"
+	case TypeNote:
+		return "note"
+	case "wiki_page":
+		return "page"
+	case "calendar_event":
+		return "event"
+	}
+	return it.PlannableType
+}
+
+// Course is the name of the item's course.
+func (it *Item) Course() string {
+	if it.ContextName == "" && it.PlannableType == TypeNote {
+		return "personal"
+	}
+	return it.ContextName
+}
+
+// Complete returns true if the item was marked as done
+// or has been submitted.
+func (it *Item) Complete() bool {
+	if it.Override != nil {
+		return it.Override.MarkedComplete
+	}
+	s := it.Submission()
+	return s != nil && (s.Submitted || s.Excused)
+}
+
+// Missing returns true if canvas says the item is missing.
+func (it *Item) Missing() bool {
+	s := it.Submission()
+	return s != nil && s.Missing && !s.Submitted
+}
+
+// Status is a short description of the item's state.
+func (it *Item) Status() string {
+	s := it.Submission()
+	switch {
+	case it.Override != nil && it.Override.MarkedComplete:
+		return "done"
+	case s == nil:
+		return ""
+	case s.Excused:
+		return "excused"
+	case s.Graded:
+		return "graded"
+	case s.Submitted && s.Late:
+		return "late"
+	case s.Submitted:
+		return "submitted"
+	case s.Missing:
+		return "missing"
+	}
+	return ""
+}
+
+// Items gets the planner items between two dates.
+func Items(start, end time.Time) ([]*Item, error) {
+	params := url.Values{
+		"start_date": {start.UTC().Format(time.RFC3339)},
+		"end_date":   {end.UTC().Format(time.RFC3339)},
+	}
+	var items []*Item
+	err := rest.Pages("planner/items", params, func(b []byte) error {
+		var page []*Item
+		if err := json.Unmarshal(b, &page); err != nil {
+			return err
+		}
+		items = append(items, page...)
+		return nil
+	})
+	return items, err
+}
+
+// Filter decides which items are shown.
+type Filter struct {
+	// All includes items that are complete.
+	All bool
+	// Unsubmitted only keeps items that can be
+	// submitted and have not been.
+	Unsubmitted bool
+	// Missing only keeps items that canvas says are missing.
+	Missing bool
+	// Courses only keeps items from these course ids.
+	Courses map[int]bool
+}
+
+// Keep returns true if an item passes the filter.
+func (f *Filter) Keep(it *Item) bool {
+	if len(f.Courses) > 0 && !f.Courses[it.CourseID] {
+		return false
+	}
+	if f.Missing && !it.Missing() {
+		return false
+	}
+	if f.Unsubmitted {
+		s := it.Submission()
+		if s == nil || s.Submitted || s.Excused {
+			return false
+		}
+	}
+	if it.Override != nil && it.Override.Dismissed {
+		return f.All
+	}
+	return f.All || !it.Complete()
+}
+
+// Relative describes when an item is due relative to now
+// (ex. "in 3h", "overdue 2d" or "2d ago").
+func Relative(it *Item, now time.Time) string {
+	d := it.PlannableDate.Sub(now)
+	switch {
+	case d >= 0 && d < time.Minute:
+		return "now"
+	case d >= 0:
+		return "in " + term.Duration(d)
+	case it.Complete() || it.Submission() == nil:
+		return term.Duration(d) + " ago"
+	}
+	return "overdue " + term.Duration(d)
+}
+
+// ParseDuration parses a duration that can also use
+// days and weeks (ex. "7d" or "2w").
+func ParseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, errors.New("empty duration")
+	}
+	for suffix, unit := range map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	} {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.ParseFloat(strings.TrimSuffix(s, suffix), 64)
+			if err != nil {
+				return 0, fmt.Errorf("bad duration %q", s)
+			}
+			return time.Duration(n * float64(unit)), nil
+		}
+	}
+	return time.ParseDuration(s)
+}
+
+// Find finds the item with a plannable id or title. An id can be
+// given with its type (ex. "quiz:12") when items of different types
+// have the same id. Titles are compared without case and can be the
+// start of a title as long as only one item matches.
+func Find(items []*Item, ident string) (*Item, error) {
+	typ, idstr := "", ident
+	if i := strings.IndexByte(ident, ':'); i > 0 {
+		typ, idstr = ident[:i], ident[i+1:]
+	}
+	id, err := strconv.Atoi(idstr)
+	if err != nil {
+		typ, id = "", -1
+	}
+	var ids, titles, prefixes []*Item
+	for _, it := range items {
+		switch {
+		case it.PlannableID == id && (typ == "" || strings.EqualFold(it.PlannableType, typ)):
+			ids = append(ids, it)
+		case strings.EqualFold(it.Plannable.Title, ident):
+			titles = append(titles, it)
+		case strings.HasPrefix(strings.ToLower(it.Plannable.Title), strings.ToLower(ident)):
+			prefixes = append(prefixes, it)
+		}
+	}
+	for _, matches := range [][]*Item{ids, titles, prefixes} {
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+			return matches[0], nil
+		}
+		names := make([]string, len(matches))
+		for i, it := range matches {
+			names[i] = fmt.Sprintf("%s:%d (%s)", it.PlannableType, it.PlannableID, it.Plannable.Title)
+		}
+		return nil, fmt.Errorf("%q matches %d items: %s", ident, len(matches), strings.Join(names, ", "))
+	}
+	return nil, fmt.Errorf("could not find %q on the planner", ident)
+}
+
+// SetComplete marks an item as done or not done with a
+// planner override, the same as the check box on the canvas
+// dashboard.
+func SetComplete(it *Item, complete bool) (*Override, error) {
+	var (
+		o    Override
+		err  error
+		form = url.Values{"marked_complete": {strconv.FormatBool(complete)}}
+	)
+	if it.Override != nil {
+		err = rest.Put(rest.Path("planner", "overrides", it.Override.ID), form, &o)
+	} else {
+		form.Set("plannable_type", it.PlannableType)
+		form.Set("plannable_id", strconv.Itoa(it.PlannableID))
+		err = rest.Post("planner/overrides", form, &o)
+	}
+	if err != nil {
+		return nil, err
+	}
+	it.Override = &o
+	return &o, nil
+}
+
+// Note is a personal planner note.
+type Note struct {
+	ID       int       `json:"id"`
+	Title    string    `json:"title"`
+	Details  string    `json:"details"`
+	TodoDate time.Time `json:"todo_date"`
+	CourseID int       `json:"course_id"`
+}
+
+// CreateNote adds a note to the planner. The note is not
+// linked to a course if the course id is zero.
+func CreateNote(title, details string, date time.Time, courseID int) (*Note, error) {
+	form := url.Values{
+		"title":     {title},
+		"todo_date": {date.UTC().Format(time.RFC3339)},
+	}
+	if details != "" {
+		form.Set("details", details)
+	}
+	if courseID != 0 {
+		form.Set("course_id", strconv.Itoa(courseID))
+	}
+	var n Note
+	if err := rest.Post("planner_notes", form, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// ParseDate parses a date for a planner note. It can be a date
+// ("2020-10-31"), a date and time ("2020-10-31 15:04"), "today",
+// "tomorrow", or a duration from now ("3d"). Dates without a time
+// are at the end of the day.
+func ParseDate(s string, now time.Time) (time.Time, error) {
+	endOfDay := func(t time.Time) time.Time {
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 23, 59, 0, 0, t.Location())
+	}
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "today":
+		return endOfDay(now), nil
+	case "tomorrow":
+		return endOfDay(now.AddDate(0, 0, 1)), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, now.Location()); err == nil {
+		return endOfDay(t), nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04", "2006-01-02T15:04", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	if d, err := ParseDuration(s); err == nil {
+		return now.Add(d), nil
+	}
+	return time.Time{}, fmt.Errorf("bad date %q (use YYYY-MM-DD, YYYY-MM-DD HH:MM, today, tomorrow, or a duration like 3d)", s)
+}
@@ -0,0 +1,163 @@
+package planner
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func item(t *testing.T, raw string) *Item {
+	t.Helper()
+	var it Item
+	if err := json.Unmarshal([]byte(raw), &it); err != nil {
+		t.Fatal(err)
+	}
+	return &it
+}
+
+func TestItem(t *testing.T) {
+	it := item(t, `{"course_id": 1, "plannable_type": "assignment", "submissions": {"submitted": false, "missing": true}}`)
+	if it.Submission() == nil || !it.Missing() || it.Complete() || it.Status() != "missing" {
+		t.Errorf("wrong state: %+v", it.Submission())
+	}
+	it = item(t, `{"plannable_type": "planner_note", "submissions": false}`)
+	if it.Submission() != nil || it.Complete() || it.Type() != "note" || it.Course() != "personal" {
+		t.Error("notes have no submission")
+	}
+	it = item(t, `{"plannable_type": "quiz", "submissions": {"submitted": true}, "planner_override": {"marked_complete": false}}`)
+	if it.Complete() || it.Status() != "submitted" {
+		t.Error("the override should win over the submission")
+	}
+	it.Override.MarkedComplete = true
+	if !it.Complete() || it.Status() != "done" {
+		t.Error("item should be done")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	var (
+		missing   = item(t, `{"course_id": 1, "submissions": {"missing": true}}`)
+		submitted = item(t, `{"course_id": 2, "submissions": {"submitted": true}}`)
+		todo      = item(t, `{"course_id": 2, "submissions": {}}`)
+		note      = item(t, `{"plannable_type": "planner_note", "submissions": false}`)
+		dismissed = item(t, `{"course_id": 1, "submissions": {}, "planner_override": {"dismissed": true}}`)
+		items     = []*Item{missing, submitted, todo, note, dismissed}
+	)
+	tests := []struct {
+		f    Filter
+		keep []*Item
+	}{
+		{Filter{}, []*Item{missing, todo, note}},
+		{Filter{All: true}, items},
+		{Filter{Missing: true}, []*Item{missing}},
+		{Filter{Unsubmitted: true}, []*Item{missing, todo}},
+		{Filter{Courses: map[int]bool{2: true}, All: true}, []*Item{submitted, todo}},
+	}
+	for i, tst := range tests {
+		var kept []*Item
+		for _, it := range items {
+			if tst.f.Keep(it) {
+				kept = append(kept, it)
+			}
+		}
+		if len(kept) != len(tst.keep) {
+			t.Errorf("test %d: expected %d items, got %d", i, len(tst.keep), len(kept))
+			continue
+		}
+		for j := range kept {
+			if kept[j] != tst.keep[j] {
+				t.Errorf("test %d: wrong item %d", i, j)
+			}
+		}
+	}
+}
+
+func TestRelative(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration, sub string) *Item {
+		it := &Item{PlannableDate: now.Add(d), RawSubmission: json.RawMessage(sub)}
+		return it
+	}
+	tests := []struct {
+		it  *Item
+		exp string
+	}{
+		{at(3*time.Hour, `{}`), "in 3h"},
+		{at(30*time.Second, `{}`), "now"},
+		{at(-50*time.Hour, `{}`), "overdue 2d 2h"},
+		{at(-50*time.Hour, `{"submitted": true}`), "2d 2h ago"},
+		{at(-time.Hour, `false`), "1h ago"},
+	}
+	for _, tst := range tests {
+		if res := Relative(tst.it, now); res != tst.exp {
+			t.Errorf("got %q; want %q", res, tst.exp)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for s, exp := range map[string]time.Duration{
+		"7d":   7 * 24 * time.Hour,
+		"1w":   7 * 24 * time.Hour,
+		"36h":  36 * time.Hour,
+		"1.5d": 36 * time.Hour,
+	} {
+		d, err := ParseDuration(s)
+		if err != nil || d != exp {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v", s, d, err, exp)
+		}
+	}
+	for _, s := range []string{"", "xd", "7"} {
+		if _, err := ParseDuration(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
+
+func TestFind(t *testing.T) {
+	items := []*Item{
+		{PlannableID: 10, Plannable: Plannable{Title: "Homework 1"}},
+		{PlannableID: 11, Plannable: Plannable{Title: "Homework 2"}},
+		{PlannableID: 12, Plannable: Plannable{Title: "Lab 1"}},
+		{PlannableID: 20, PlannableType: "assignment", Plannable: Plannable{Title: "Essay"}},
+		{PlannableID: 20, PlannableType: "quiz", Plannable: Plannable{Title: "Quiz 1"}},
+	}
+	for ident, exp := range map[string]*Item{
+		"11":         items[1],
+		"homework 1": items[0],
+		"lab":        items[2],
+		"quiz:20":    items[4],
+		"Quiz:20":    items[4],
+	} {
+		it, err := Find(items, ident)
+		if err != nil {
+			t.Errorf("%q: %v", ident, err)
+		} else if it != exp {
+			t.Errorf("%q: got %q; want %q", ident, it.Plannable.Title, exp.Plannable.Title)
+		}
+	}
+	for _, ident := range []string{"homework", "exam", "13", "20", "discussion_topic:20"} {
+		if _, err := Find(items, ident); err == nil {
+			t.Errorf("expected an error for %q", ident)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	for s, exp := range map[string]time.Time{
+		"":                 time.Date(2020, 10, 1, 23, 59, 0, 0, time.UTC),
+		"Tomorrow":         time.Date(2020, 10, 2, 23, 59, 0, 0, time.UTC),
+		"2020-10-31":       time.Date(2020, 10, 31, 23, 59, 0, 0, time.UTC),
+		"2020-10-31 08:30": time.Date(2020, 10, 31, 8, 30, 0, 0, time.UTC),
+		"3d":               now.Add(72 * time.Hour),
+	} {
+		d, err := ParseDate(s, now)
+		if err != nil || !d.Equal(exp) {
+			t.Errorf("ParseDate(%q) = %v, %v; want %v", s, d, err, exp)
+		}
+	}
+	if _, err := ParseDate("next week", now); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,221 @@
+// Package rest is a small client for the parts of the canvas
+// api that are not covered by the go-canvas package.
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	mu     sync.RWMutex
+	host   = "canvas.instructure.com"
+	token  string
+	client = http.DefaultClient
+)
+
+// SetHost sets the canvas host.
+func SetHost(h string) {
+	mu.Lock()
+	host = h
+	mu.Unlock()
+}
+
+// SetToken sets the canvas api token.
+func SetToken(t string) {
+	mu.Lock()
+	token = t
+	mu.Unlock()
+}
+
+// SetTransport sets the transport used for all requests.
+func SetTransport(rt http.RoundTripper) {
+	mu.Lock()
+	client = &http.Client{Transport: rt}
+	mu.Unlock()
+}
+
+// Host returns the canvas host.
+func Host() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return host
+}
+
+// Error is an error response from canvas.
+type Error struct {
+	Status   int
+	Messages []string
+}
+
+func (e *Error) Error() string {
+	if len(e.Messages) == 0 {
+		return fmt.Sprintf("canvas: %s", http.StatusText(e.Status))
+	}
+	return fmt.Sprintf("canvas: %s", strings.Join(e.Messages, ", "))
+}
+
+// URL returns the full api url for a path.
+func URL(path string, params url.Values) string {
+	u := url.URL{
+		Scheme: "https",
+		Host:   Host(),
+		Path:   "/api/v1/" + strings.TrimPrefix(path, "/"),
+	}
+	if params != nil {
+		u.RawQuery = params.Encode()
+	}
+	return u.String()
+}
+
+// Path joins the parts of an api path. Ints are formatted
+// and strings are escaped.
+func Path(parts ...interface{}) string {
+	strs := make([]string, len(parts))
+	for i, p := range parts {
+		switch v := p.(type) {
+		case int:
+			strs[i] = strconv.Itoa(v)
+		case string:
+			strs[i] = url.PathEscape(v)
+		default:
+			strs[i] = url.PathEscape(fmt.Sprint(v))
+		}
+	}
+	return strings.Join(strs, "/")
+}
+
+// Do sends a request. The api token is only added
+// for requests to the canvas host.
+func Do(req *http.Request) (*http.Response, error) {
+	mu.RLock()
+	if req.URL.Host == host && token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	c := client
+	mu.RUnlock()
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		return nil, parseError(resp)
+	}
+	return resp, nil
+}
+
+// Get will get a path and decode the json response into v.
+func Get(path string, params url.Values, v interface{}) error {
+	_, err := get(URL(path, params), v)
+	return err
+}
+
+// Pages will call fn with the body of every page of a paginated
+// resource. Use this for endpoints that return lists.
+func Pages(path string, params url.Values, fn func(page []byte) error) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	if params.Get("per_page") == "" {
+		params.Set("per_page", "50")
+	}
+	next := URL(path, params)
+	for next != "" {
+		var body json.RawMessage
+		resp, err := get(next, &body)
+		if err != nil {
+			return err
+		}
+		if err = fn(body); err != nil {
+			return err
+		}
+		next = nextLink(resp.Header.Get("Link"))
+	}
+	return nil
+}
+
+// Post sends a form to a path and decodes the json response into v.
+func Post(path string, form url.Values, v interface{}) error {
+	return sendForm("POST", path, form, v)
+}
+
+// Put sends a form to a path with a PUT request and
+// decodes the json response into v.
+func Put(path string, form url.Values, v interface{}) error {
+	return sendForm("PUT", path, form, v)
+}
+
+func sendForm(method, path string, form url.Values, v interface{}) error {
+	req, err := http.NewRequest(method, URL(path, nil), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	_, err = send(req, v)
+	return err
+}
+
+func get(u string, v interface{}) (*http.Response, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return send(req, v)
+}
+
+func send(req *http.Request, v interface{}) (*http.Response, error) {
+	req.Header.Set("Accept", "application/json")
+	resp, err := Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if v == nil {
+		_, err = io.Copy(ioutil.Discard, resp.Body)
+		return resp, err
+	}
+	return resp, json.NewDecoder(resp.Body).Decode(v)
+}
+
+var linkRegex = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+func nextLink(header string) string {
+	m := linkRegex.FindStringSubmatch(header)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+func parseError(resp *http.Response) error {
+	e := &Error{Status: resp.StatusCode}
+	var body struct {
+		Errors  json.RawMessage `json:"errors"`
+		Message string          `json:"message"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return e
+	}
+	if body.Message != "" {
+		e.Messages = append(e.Messages, body.Message)
+	}
+	// errors can be a list of objects or an object of lists
+	var list []struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body.Errors, &list) == nil {
+		for _, m := range list {
+			e.Messages = append(e.Messages, m.Message)
+		}
+	}
+	return e
+}
@@ -0,0 +1,60 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNextLink(t *testing.T) {
+	header := `<https://canvas.instructure.com/api/v1/courses/1/modules?page=1>; rel="current",` +
+		`<https://canvas.instructure.com/api/v1/courses/1/modules?page=2>; rel="next",` +
+		`<https://canvas.instructure.com/api/v1/courses/1/modules?page=1>; rel="first"`
+	if next := nextLink(header); next != "https://canvas.instructure.com/api/v1/courses/1/modules?page=2" {
+		t.Errorf("wrong next link: %q", next)
+	}
+	if next := nextLink(`<https://x.com/a?page=1>; rel="current"`); next != "" {
+		t.Errorf("expected no next link, got %q", next)
+	}
+}
+
+func TestSendForm(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"method": "` + r.Method + `", "path": "` + r.URL.Path + `", "title": "` + r.PostForm.Get("title") + `"}`))
+	}))
+	defer srv.Close()
+	oldHost, oldClient := Host(), client
+	defer func() {
+		SetHost(oldHost)
+		SetToken("")
+		client = oldClient
+	}()
+	SetHost(strings.TrimPrefix(srv.URL, "https://"))
+	SetToken("test-token")
+	client = srv.Client()
+
+	var resp struct {
+		Method, Path, Title string
+	}
+	if err := Post("planner_notes", url.Values{"title": {"study"}}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Method != "POST" || resp.Path != "/api/v1/planner_notes" || resp.Title != "study" {
+		t.Errorf("wrong request: %+v", resp)
+	}
+	if err := Put(Path("planner", "overrides", 5), url.Values{"title": {"x"}}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Method != "PUT" || resp.Path != "/api/v1/planner/overrides/5" {
+		t.Errorf("wrong request: %+v", resp)
+	}
+}
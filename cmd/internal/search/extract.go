@@ -0,0 +1,48 @@
+package search
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/harrybrwn/edu/pkg/pdftext"
+	"github.com/jaytaylor/html2text"
+)
+
+// files bigger than maxSize are not indexed
+const maxSize = 100 << 20
+
+var textExts = map[string]bool{
+	".txt": true, ".md": true, ".markdown": true, ".rst": true,
+	".csv": true, ".tex": true, ".c": true, ".h": true, ".cpp": true,
+	".java": true, ".py": true, ".go": true, ".js": true, ".s": true,
+}
+
+// Supported returns true if the text of a file can be extracted.
+func Supported(filename string) bool {
+	ext := strings.ToLower(filepath.Ext(filename))
+	switch ext {
+	case ".pdf", ".html", ".htm":
+		return true
+	}
+	return textExts[ext]
+}
+
+// Extract returns the text of a file.
+func Extract(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".pdf":
+		return pdftext.File(filename)
+	case ".html", ".htm":
+		b, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return "", err
+		}
+		return html2text.FromString(string(b), html2text.Options{OmitLinks: true})
+	}
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
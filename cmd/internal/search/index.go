@@ -0,0 +1,323 @@
+// Package search keeps a full text index of the
+// files downloaded by edu update.
+package search
+
+import (
+	"encoding/gob"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/harrybrwn/edu/cmd/internal/files"
+)
+
+const indexFile = "index.gob"
+
+// Doc is an indexed file.
+type Doc struct {
+	// Path is relative to the base directory.
+	Path    string
+	Course  string
+	Size    int64
+	ModTime time.Time
+	// Length is the number of words in the document.
+	Length int
+	// Words is the set of words in the document.
+	Words []string
+}
+
+// Index is an inverted index of the files in a base directory.
+type Index struct {
+	basedir string
+	Docs    map[string]*Doc
+	// Postings maps a word to the number of
+	// times it is in each document.
+	Postings map[string]map[string]int
+}
+
+// Open reads the index for a base directory. If there
+// is no index yet, an empty one is returned.
+func Open(basedir string) (*Index, error) {
+	ix := &Index{
+		basedir:  basedir,
+		Docs:     make(map[string]*Doc),
+		Postings: make(map[string]map[string]int),
+	}
+	f, err := os.Open(ix.filename())
+	if os.IsNotExist(err) {
+		return ix, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if err = gob.NewDecoder(f).Decode(ix); err != nil {
+		// the index is only a cache so start over
+		return &Index{
+			basedir:  basedir,
+			Docs:     make(map[string]*Doc),
+			Postings: make(map[string]map[string]int),
+		}, nil
+	}
+	return ix, nil
+}
+
+func (ix *Index) filename() string {
+	return filepath.Join(ix.basedir, files.MetaDir, indexFile)
+}
+
+// Save writes the index to disk.
+func (ix *Index) Save() (err error) {
+	dir := filepath.Join(ix.basedir, files.MetaDir)
+	if err = os.MkdirAll(dir, 0775); err != nil {
+		return err
+	}
+	tmp := ix.filename() + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err = gob.NewEncoder(f).Encode(ix); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, ix.filename())
+}
+
+// Update will index every new or changed file in the base directory
+// and remove the files that no longer exist. It returns the number of
+// files that were indexed and removed.
+func (ix *Index) Update() (indexed, removed int, err error) {
+	found := make(map[string]bool)
+	err = filepath.Walk(ix.basedir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == ix.basedir {
+				return err
+			}
+			return nil // skip files we can't read
+		}
+		name := info.Name()
+		if info.IsDir() {
+			if path != ix.basedir && strings.HasPrefix(name, ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(name, ".") || !Supported(name) || info.Size() > maxSize {
+			return nil
+		}
+		rel, err := filepath.Rel(ix.basedir, path)
+		if err != nil {
+			return err
+		}
+		found[rel] = true
+		if doc, ok := ix.Docs[rel]; ok && doc.Size == info.Size() && doc.ModTime.Equal(info.ModTime()) {
+			return nil
+		}
+		// files that can't be read are still added so that
+		// they are not read again until they change
+		text, _ := Extract(path)
+		ix.add(&Doc{
+			Path:    rel,
+			Course:  course(rel),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		}, text)
+		indexed++
+		return nil
+	})
+	if err != nil {
+		return indexed, removed, err
+	}
+	for path := range ix.Docs {
+		if !found[path] {
+			ix.remove(path)
+			removed++
+		}
+	}
+	return indexed, removed, nil
+}
+
+func (ix *Index) add(doc *Doc, text string) {
+	ix.remove(doc.Path)
+	counts := make(map[string]int)
+	for _, w := range Tokenize(text) {
+		counts[w]++
+		doc.Length++
+	}
+	doc.Words = make([]string, 0, len(counts))
+	for w, n := range counts {
+		doc.Words = append(doc.Words, w)
+		p, ok := ix.Postings[w]
+		if !ok {
+			p = make(map[string]int)
+			ix.Postings[w] = p
+		}
+		p[doc.Path] = n
+	}
+	ix.Docs[doc.Path] = doc
+}
+
+func (ix *Index) remove(path string) {
+	doc, ok := ix.Docs[path]
+	if !ok {
+		return
+	}
+	for _, w := range doc.Words {
+		delete(ix.Postings[w], path)
+		if len(ix.Postings[w]) == 0 {
+			delete(ix.Postings, w)
+		}
+	}
+	delete(ix.Docs, path)
+}
+
+// Hit is a search result.
+type Hit struct {
+	*Doc
+	Score float64
+}
+
+// Search returns the documents that have every word in the query
+// ranked by tf-idf. If no document has every word, then documents
+// with any of the words are returned.
+func (ix *Index) Search(query string) []Hit {
+	words := Tokenize(query)
+	if len(words) == 0 {
+		return nil
+	}
+	scores := make(map[string]float64)
+	matched := make(map[string]int)
+	for _, w := range uniq(words) {
+		postings := ix.Postings[w]
+		if len(postings) == 0 {
+			continue
+		}
+		idf := math.Log(1 + float64(len(ix.Docs))/float64(len(postings)))
+		for path, n := range postings {
+			doc := ix.Docs[path]
+			tf := float64(n) / math.Sqrt(float64(doc.Length))
+			scores[path] += tf * idf
+			matched[path]++
+		}
+	}
+	all := len(uniq(words))
+	hasAll := false
+	for _, n := range matched {
+		if n == all {
+			hasAll = true
+			break
+		}
+	}
+	hits := make([]Hit, 0, len(scores))
+	for path, score := range scores {
+		if hasAll && matched[path] < all {
+			continue
+		}
+		hits = append(hits, Hit{Doc: ix.Docs[path], Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score == hits[j].Score {
+			return hits[i].Path < hits[j].Path
+		}
+		return hits[i].Score > hits[j].Score
+	})
+	return hits
+}
+
+// Snippet returns the part of a document around the
+// first place that a word in the query is found.
+func (ix *Index) Snippet(doc *Doc, query string, width int) string {
+	text, err := Extract(filepath.Join(ix.basedir, doc.Path))
+	if err != nil {
+		return ""
+	}
+	text = strings.Join(strings.Fields(text), " ")
+	lower := strings.ToLower(text)
+	at := -1
+	for _, w := range Tokenize(query) {
+		if i := strings.Index(lower, w); i >= 0 && (at < 0 || i < at) {
+			at = i
+		}
+	}
+	if at < 0 || at >= len(text) {
+		at = 0
+	}
+	start, end := at-width/2, at+width/2
+	if start < 0 {
+		start, end = 0, end-start
+	}
+	if end > len(text) {
+		end = len(text)
+	}
+	// don't cut a character in half
+	for start > 0 && start < len(text) && !utf8Start(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8Start(text[end]) {
+		end++
+	}
+	snippet := text[start:end]
+	if start > 0 {
+		snippet = "..." + snippet
+	}
+	if end < len(text) {
+		snippet += "..."
+	}
+	return snippet
+}
+
+func utf8Start(b byte) bool { return b&0xC0 != 0x80 }
+
+var stopWords = map[string]bool{
+	"the": true, "and": true, "of": true, "to": true, "in": true,
+	"is": true, "it": true, "for": true, "on": true, "an": true,
+	"be": true, "as": true, "at": true, "by": true, "or": true,
+	"this": true, "that": true, "with": true, "are": true, "from": true,
+}
+
+// Tokenize splits text into lowercase words
+// without the most common english words.
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words := fields[:0]
+	for _, f := range fields {
+		if len(f) < 2 || len(f) > 40 || stopWords[f] {
+			continue
+		}
+		words = append(words, f)
+	}
+	return words
+}
+
+func uniq(words []string) []string {
+	seen := make(map[string]bool)
+	result := make([]string, 0, len(words))
+	for _, w := range words {
+		if !seen[w] {
+			seen[w] = true
+			result = append(result, w)
+		}
+	}
+	return result
+}
+
+// course guesses the course of a file from the first
+// folder in its path.
+func course(rel string) string {
+	parts := strings.SplitN(filepath.ToSlash(rel), "/", 2)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[0]
+}
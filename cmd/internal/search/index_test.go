@@ -0,0 +1,104 @@
+package search
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	words := Tokenize("The AVL-tree rotations, and O(log n) time!")
+	exp := []string{"avl", "tree", "rotations", "log", "time"}
+	if !reflect.DeepEqual(words, exp) {
+		t.Errorf("got %v, want %v", words, exp)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "edu-search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, content string) {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("CSE 100/notes/avl.md", "# AVL trees\nA rotation keeps the AVL tree balanced. Rotations are cheap.")
+	write("CSE 100/notes/heaps.txt", "Heaps are trees too, but there is no rotation here.")
+	write("MATH 24/week1.html", "<p>Linear <b>algebra</b> and matrices</p>")
+	write("MATH 24/slides.pptx", "not indexed")
+	write(".edu/manifest.json", "[]")
+
+	ix, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexed, _, err := ix.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if indexed != 3 {
+		t.Errorf("expected 3 indexed files, got %d", indexed)
+	}
+	if err = ix.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	ix, err = Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	indexed, removed, err := ix.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if indexed != 0 || removed != 0 {
+		t.Errorf("nothing should change, indexed %d and removed %d", indexed, removed)
+	}
+
+	hits := ix.Search("avl rotation")
+	if len(hits) != 1 || hits[0].Path != filepath.Join("CSE 100", "notes", "avl.md") {
+		t.Fatalf("wrong hits: %v", hits)
+	}
+	if hits[0].Course != "CSE 100" {
+		t.Errorf("wrong course %q", hits[0].Course)
+	}
+	if s := ix.Snippet(hits[0].Doc, "rotation", 30); !strings.Contains(s, "rotation") {
+		t.Errorf("bad snippet %q", s)
+	}
+	if hits = ix.Search("rotation"); len(hits) != 2 {
+		t.Errorf("expected 2 hits, got %d", len(hits))
+	}
+	if hits = ix.Search("algebra"); len(hits) != 1 {
+		t.Errorf("html files should be indexed")
+	}
+
+	// changed and removed files
+	os.Remove(filepath.Join(dir, "MATH 24", "week1.html"))
+	write("CSE 100/notes/heaps.txt", "Heaps now talk about priority queues.")
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(filepath.Join(dir, "CSE 100", "notes", "heaps.txt"), later, later)
+	indexed, removed, err = ix.Update()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if indexed != 1 || removed != 1 {
+		t.Errorf("expected 1 indexed and 1 removed, got %d and %d", indexed, removed)
+	}
+	if hits = ix.Search("rotation"); len(hits) != 1 {
+		t.Errorf("old words should be removed from the index, got %d hits", len(hits))
+	}
+	if _, ok := ix.Postings["algebra"]; ok {
+		t.Error("words from removed files should be removed")
+	}
+}
@@ -0,0 +1,70 @@
+// Package submit checks assignment submissions
+// before they are sent to canvas.
+package submit
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/harrybrwn/edu/cmd/internal/rest"
+)
+
+// Submission types
+const (
+	OnlineUpload    = "online_upload"
+	OnlineTextEntry = "online_text_entry"
+	OnlineURL       = "online_url"
+)
+
+// Assignment has the assignment settings that
+// limit what can be submitted.
+type Assignment struct {
+	ID                int         `json:"id"`
+	CourseID          int         `json:"course_id"`
+	Name              string      `json:"name"`
+	SubmissionTypes   []string    `json:"submission_types"`
+	AllowedExtensions []string    `json:"allowed_extensions"`
+	AllowedAttempts   int         `json:"allowed_attempts"` // -1 means unlimited
+	DueAt             *time.Time  `json:"due_at"`
+	LockAt            *time.Time  `json:"lock_at"`
+	UnlockAt          *time.Time  `json:"unlock_at"`
+	LockedForUser     bool        `json:"locked_for_user"`
+	LockExplanation   string      `json:"lock_explanation"`
+	Submission        *Submission `json:"submission"`
+}
+
+// Submission is the user's latest submission for an assignment.
+type Submission struct {
+	ID            int        `json:"id"`
+	Attempt       int        `json:"attempt"`
+	WorkflowState string     `json:"workflow_state"`
+	SubmittedAt   *time.Time `json:"submitted_at"`
+	Late          bool       `json:"late"`
+	Missing       bool       `json:"missing"`
+}
+
+// GetAssignment gets an assignment with the user's submission.
+func GetAssignment(courseID, id int) (*Assignment, error) {
+	var as Assignment
+	params := url.Values{"include[]": {"submission"}}
+	err := rest.Get(rest.Path("courses", courseID, "assignments", id), params, &as)
+	if err != nil {
+		return nil, err
+	}
+	return &as, nil
+}
+
+// Quota is the user's file storage quota in bytes.
+type Quota struct {
+	Quota     int64 `json:"quota"`
+	QuotaUsed int64 `json:"quota_used"`
+}
+
+// GetQuota gets the user's file quota.
+func GetQuota() (*Quota, error) {
+	var q Quota
+	if err := rest.Get("users/self/files/quota", nil, &q); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
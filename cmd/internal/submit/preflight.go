@@ -0,0 +1,153 @@
+package submit
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/harrybrwn/edu/pkg/term"
+)
+
+// File is a file that will be uploaded for a submission.
+type File struct {
+	Name string
+	Size int64
+}
+
+// Preflight is the result of checking a submission
+// before it is sent.
+type Preflight struct {
+	Assignment *Assignment
+	Type       string
+	Files      []File
+	// Attempt is the number the new submission will have.
+	Attempt int
+	// Late is how late the submission is, zero if it is on time.
+	Late time.Duration
+	// Problems are the reasons canvas would probably
+	// reject the submission.
+	Problems []string
+}
+
+// OK returns true if no problems were found.
+func (p *Preflight) OK() bool { return len(p.Problems) == 0 }
+
+// Check will check a submission of some type against the assignment
+// settings. The quota is optional and only used for file uploads.
+func Check(as *Assignment, typ string, files []File, quota *Quota, now time.Time) *Preflight {
+	p := &Preflight{Assignment: as, Type: typ, Files: files, Attempt: 1}
+	if as.Submission != nil {
+		p.Attempt = as.Submission.Attempt + 1
+	}
+	if !contains(as.SubmissionTypes, typ) {
+		p.problem("%s submissions are not allowed (allowed: %s)", typ, strings.Join(as.SubmissionTypes, ", "))
+	}
+	switch {
+	case as.UnlockAt != nil && now.Before(*as.UnlockAt):
+		p.problem("assignment is not unlocked until %s", as.UnlockAt.Local().Format(time.RFC822))
+	case as.LockAt != nil && now.After(*as.LockAt):
+		p.problem("assignment was locked at %s", as.LockAt.Local().Format(time.RFC822))
+	case as.LockedForUser:
+		if as.LockExplanation != "" {
+			p.problem("assignment is locked: %s", as.LockExplanation)
+		} else {
+			p.problem("assignment is locked")
+		}
+	}
+	if as.AllowedAttempts > 0 && p.Attempt > as.AllowedAttempts {
+		p.problem("no attempts left (%d allowed)", as.AllowedAttempts)
+	}
+	if as.DueAt != nil && now.After(*as.DueAt) {
+		p.Late = now.Sub(*as.DueAt)
+	}
+	if typ != OnlineUpload {
+		return p
+	}
+	if len(files) == 0 {
+		p.problem("no files given")
+	}
+	var total int64
+	for _, f := range files {
+		ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(f.Name)), ".")
+		if len(as.AllowedExtensions) > 0 && !containsFold(as.AllowedExtensions, ext) {
+			p.problem("%s: extension not allowed (allowed: %s)", f.Name, strings.Join(as.AllowedExtensions, ", "))
+		}
+		if f.Size == 0 {
+			p.problem("%s: file is empty", f.Name)
+		}
+		total += f.Size
+	}
+	if quota != nil && quota.Quota > 0 && quota.QuotaUsed+total > quota.Quota {
+		p.problem("files are too big for your canvas quota (%s left)", term.Bytes(quota.Quota-quota.QuotaUsed))
+	}
+	return p
+}
+
+func (p *Preflight) problem(format string, v ...interface{}) {
+	p.Problems = append(p.Problems, fmt.Sprintf(format, v...))
+}
+
+// WriteTo writes a summary of the submission.
+func (p *Preflight) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	as := p.Assignment
+	fmt.Fprintf(&b, "assignment: %s (%d)\n", as.Name, as.ID)
+	fmt.Fprintf(&b, "type:       %s\n", p.Type)
+	for i, f := range p.Files {
+		label := ""
+		if i == 0 {
+			label = "files:"
+		}
+		fmt.Fprintf(&b, "%-11s %s (%s)\n", label, f.Name, term.Bytes(f.Size))
+	}
+	switch {
+	case as.DueAt == nil:
+		b.WriteString("due:        no due date\n")
+	case p.Late > 0:
+		fmt.Fprintf(&b, "due:        %s (late by %s)\n", as.DueAt.Local().Format(time.RFC822), term.Duration(p.Late))
+	default:
+		fmt.Fprintf(&b, "due:        %s\n", as.DueAt.Local().Format(time.RFC822))
+	}
+	if as.AllowedAttempts > 0 {
+		fmt.Fprintf(&b, "attempt:    %d of %d\n", p.Attempt, as.AllowedAttempts)
+	} else {
+		fmt.Fprintf(&b, "attempt:    %d\n", p.Attempt)
+	}
+	for _, prob := range p.Problems {
+		fmt.Fprintf(&b, "problem:    %s\n", prob)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// Confirm asks a yes or no question and returns true if the answer is yes.
+func Confirm(r io.Reader, w io.Writer, question string) bool {
+	fmt.Fprintf(w, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(r).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, l := range list {
+		if strings.EqualFold(strings.TrimPrefix(l, "."), s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package submit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2020, 10, 5, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+	as := &Assignment{
+		ID:                1,
+		Name:              "hw1",
+		SubmissionTypes:   []string{OnlineUpload},
+		AllowedExtensions: []string{"pdf", "PY"},
+		AllowedAttempts:   2,
+		DueAt:             at(-2 * time.Hour),
+		LockAt:            at(time.Hour),
+		Submission:        &Submission{Attempt: 1},
+	}
+	p := Check(as, OnlineUpload, []File{{"a.pdf", 10}, {"b.py", 5}}, nil, now)
+	if !p.OK() {
+		t.Errorf("expected no problems, got %v", p.Problems)
+	}
+	if p.Attempt != 2 {
+		t.Errorf("expected attempt 2, got %d", p.Attempt)
+	}
+	if p.Late != 2*time.Hour {
+		t.Errorf("expected to be late by 2h, got %v", p.Late)
+	}
+	var buf bytes.Buffer
+	if _, err := p.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"attempt:    2 of 2", "late by 2h", "b.py (5B)"} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("expected %q in summary:\n%s", s, buf.String())
+		}
+	}
+
+	as.Submission.Attempt = 2
+	as.LockAt = at(-time.Hour)
+	p = Check(as, OnlineUpload, []File{{"a.zip", 10}, {"b.pdf", 0}}, &Quota{Quota: 100, QuotaUsed: 95}, now)
+	expected := []string{
+		"assignment was locked",
+		"no attempts left",
+		"a.zip: extension not allowed",
+		"b.pdf: file is empty",
+		"too big for your canvas quota",
+	}
+	if len(p.Problems) != len(expected) {
+		t.Fatalf("expected %d problems, got %v", len(expected), p.Problems)
+	}
+	for i, exp := range expected {
+		if !strings.Contains(p.Problems[i], exp) {
+			t.Errorf("expected problem %q, got %q", exp, p.Problems[i])
+		}
+	}
+
+	p = Check(&Assignment{SubmissionTypes: []string{OnlineUpload}, UnlockAt: at(time.Hour)}, OnlineURL, nil, nil, now)
+	if len(p.Problems) != 2 || p.Attempt != 1 || p.Late != 0 {
+		t.Errorf("wrong preflight: %+v", p)
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	var out bytes.Buffer
+	for in, exp := range map[string]bool{"y\n": true, "Yes\n": true, "n\n": false, "\n": false, "": false} {
+		if res := Confirm(strings.NewReader(in), &out, "ok?"); res != exp {
+			t.Errorf("wrong answer for %q: got %v", in, res)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "edu-submit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	recs, err := ReadHistory(dir)
+	if err != nil || len(recs) != 0 {
+		t.Fatalf("expected empty history, got %v, %v", recs, err)
+	}
+	for i := 1; i <= 2; i++ {
+		if err = AddHistory(dir, Record{AssignmentID: 5, SubmissionID: 10, Attempt: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if recs, err = ReadHistory(dir); err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 || recs[1].Attempt != 2 {
+		t.Errorf("wrong history: %+v", recs)
+	}
+}
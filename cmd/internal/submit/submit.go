@@ -0,0 +1,131 @@
+package submit
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harrybrwn/edu/cmd/internal/files"
+	"github.com/harrybrwn/edu/cmd/internal/rest"
+)
+
+// ErrEmpty is returned when the text written in the editor is empty.
+var ErrEmpty = errors.New("empty submission")
+
+// editHeader is put at the top of the file being edited and
+// removed before the text is submitted.
+const editHeader = "<!-- Write your submission in markdown. This comment is removed. -->\n"
+
+// Edit opens a markdown file in an editor and returns what was written.
+// The editor is a command (ex. "code --wait") and vi is used if it is
+// empty.
+func Edit(editor, initial string) (text string, err error) {
+	f, err := ioutil.TempFile("", "edu-submission-*.md")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.WriteString(editHeader + initial)
+	if e := f.Close(); e != nil && err == nil {
+		err = e
+	}
+	if err != nil {
+		return "", err
+	}
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+	cmd := exec.Command(args[0], append(args[1:], f.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err = cmd.Run(); err != nil {
+		return "", err
+	}
+	b, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		return "", err
+	}
+	text = strings.TrimSpace(strings.Replace(string(b), strings.TrimSpace(editHeader), "", 1))
+	if text == "" {
+		return "", ErrEmpty
+	}
+	return text, nil
+}
+
+// Create makes a new submission. For file uploads, the files must already
+// be uploaded to the assignment and their ids given as fileIDs. The body is
+// only used for text entries and the url only for url submissions.
+func Create(as *Assignment, typ string, fileIDs []int, body, u string) (*Submission, error) {
+	form := url.Values{"submission[submission_type]": {typ}}
+	switch typ {
+	case OnlineUpload:
+		for _, id := range fileIDs {
+			form.Add("submission[file_ids][]", strconv.Itoa(id))
+		}
+	case OnlineTextEntry:
+		form.Set("submission[body]", body)
+	case OnlineURL:
+		form.Set("submission[url]", u)
+	}
+	var sub Submission
+	err := rest.Post(rest.Path("courses", as.CourseID, "assignments", as.ID, "submissions"), form, &sub)
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// HistoryFile is the file in the base directory
+// that keeps a record of every submission.
+var HistoryFile = filepath.Join(files.MetaDir, "submissions.json")
+
+// Record is a submission made with edu.
+type Record struct {
+	CourseID     int       `json:"course_id"`
+	AssignmentID int       `json:"assignment_id"`
+	Assignment   string    `json:"assignment"`
+	SubmissionID int       `json:"submission_id"`
+	Attempt      int       `json:"attempt"`
+	Type         string    `json:"type"`
+	Files        []string  `json:"files,omitempty"`
+	URL          string    `json:"url,omitempty"`
+	SubmittedAt  time.Time `json:"submitted_at"`
+}
+
+// ReadHistory reads every submission record in the base directory.
+func ReadHistory(basedir string) ([]Record, error) {
+	b, err := ioutil.ReadFile(filepath.Join(basedir, HistoryFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var recs []Record
+	return recs, json.Unmarshal(b, &recs)
+}
+
+// AddHistory adds a record to the submission history.
+func AddHistory(basedir string, rec Record) error {
+	recs, err := ReadHistory(basedir)
+	if err != nil {
+		return err
+	}
+	recs = append(recs, rec)
+	b, err := json.MarshalIndent(recs, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := filepath.Join(basedir, HistoryFile)
+	if err = os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, b, 0644)
+}
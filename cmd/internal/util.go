@@ -94,9 +94,13 @@ func FindCourse(identifier interface{}, opts ...canvas.Option) (*canvas.Course,
 			}
 		}
 	}
-	return nil, errors.New("could not find course")
+	return nil, ErrCourseNotFound
 }
 
+// ErrCourseNotFound is returned by FindCourse when no
+// course matches the identifier.
+var ErrCourseNotFound = errors.New("could not find course")
+
 var errAssignmentNotFound = errors.New("could not find assignment")
 
 // FindAssignment will find an assignment the matches a generic identifier.
@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	stdlog "log"
+	"net/http"
 	"os"
 	"path/filepath"
 
@@ -11,6 +12,8 @@ import (
 	"github.com/harrybrwn/edu/cmd/commands"
 	"github.com/harrybrwn/edu/cmd/internal"
 	"github.com/harrybrwn/edu/cmd/internal/opts"
+	"github.com/harrybrwn/edu/cmd/internal/rest"
+	"github.com/harrybrwn/edu/pkg/throttle"
 	"github.com/harrybrwn/errs"
 	"github.com/harrybrwn/go-canvas"
 	"github.com/pkg/errors"
@@ -34,12 +37,11 @@ var Logger = &lumberjack.Logger{
 func Stop(message interface{}) {
 	log.Printf("%v\n", message)
 	fmt.Fprintf(os.Stderr, "%v\n", message)
-	switch msg := message.(type) {
-	case *internal.Error:
-		os.Exit(msg.Code)
-	default:
-		os.Exit(1)
+	var e *internal.Error
+	if err, ok := message.(error); ok && errors.As(err, &e) {
+		os.Exit(e.Code)
 	}
+	os.Exit(1)
 }
 
 // Execute will execute the root comand on the cli
@@ -111,8 +113,20 @@ func rootPreRun() {
 	if token == "" {
 		log.Println("no canvas api token")
 	}
+	// The canvas client copies the default transport when it is
+	// created, so it is only swapped out while the client is made
+	// and no other http client gets the canvas retries.
+	var (
+		base      = http.DefaultTransport
+		throttled = throttle.New(base, canvas.DefaultHost)
+	)
+	http.DefaultTransport = throttled
 	canvas.SetToken(token)
+	http.DefaultTransport = base
 	canvas.ConcurrentErrorHandler = errorHandler
+	rest.SetHost(canvas.DefaultHost)
+	rest.SetToken(token)
+	rest.SetTransport(throttled)
 }
 
 var (
@@ -179,12 +193,15 @@ func errorMessage(err error) {
 	}
 }
 
+// errorHandler collects errors from concurrent canvas requests
+// so that commands can report them once they are finished. Every
+// command that can reach it has to end with internal.Errors.Finish.
 func errorHandler(e error) error {
 	if e != nil {
-		errmsg(e.Error())
-		os.Exit(2)
+		log.Println(e)
+		internal.Errors.Add("", e)
 	}
-	return nil
+	return e
 }
 
 func errmsg(msg interface{}) {
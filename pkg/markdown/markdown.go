@@ -0,0 +1,256 @@
+// Package markdown converts a small subset of markdown to html.
+//
+// Supported are paragraphs, atx headings, fenced and indented
+// code blocks, block quotes, ordered and unordered lists, thematic
+// breaks, and the inline code, emphasis, link, image and autolink
+// syntax. Raw html is escaped.
+package markdown
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ToHTML converts markdown to html.
+func ToHTML(src string) string {
+	var b strings.Builder
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	render(&b, lines)
+	return b.String()
+}
+
+var (
+	headingRe = regexp.MustCompile(`^(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	ruleRe    = regexp.MustCompile(`^ {0,3}((\*[ \t]*){3,}|(-[ \t]*){3,}|(_[ \t]*){3,})$`)
+	bulletRe  = regexp.MustCompile(`^ {0,3}([-*+])[ \t]+(.*)$`)
+	orderedRe = regexp.MustCompile(`^ {0,3}(\d{1,9})[.)][ \t]+(.*)$`)
+	fenceRe   = regexp.MustCompile("^ {0,3}(```+|~~~+)[ \t]*([^`\\s]*)")
+)
+
+func render(b *strings.Builder, lines []string) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			i++
+		case fenceRe.MatchString(line):
+			m := fenceRe.FindStringSubmatch(line)
+			fence := m[1]
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+					i++
+					break
+				}
+				code = append(code, lines[i])
+			}
+			writeCode(b, code, m[2])
+		case strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t"):
+			var code []string
+			for ; i < len(lines); i++ {
+				l := lines[i]
+				if strings.TrimSpace(l) != "" && !strings.HasPrefix(l, "    ") && !strings.HasPrefix(l, "\t") {
+					break
+				}
+				code = append(code, strings.TrimPrefix(strings.TrimPrefix(l, "\t"), "    "))
+			}
+			for len(code) > 0 && strings.TrimSpace(code[len(code)-1]) == "" {
+				code = code[:len(code)-1]
+			}
+			writeCode(b, code, "")
+		case headingRe.MatchString(trimmed):
+			m := headingRe.FindStringSubmatch(trimmed)
+			fmt.Fprintf(b, "<h%[1]d>%[2]s</h%[1]d>\n", len(m[1]), inline(m[2]))
+			i++
+		case ruleRe.MatchString(line):
+			b.WriteString("<hr>\n")
+			i++
+		case strings.HasPrefix(trimmed, ">"):
+			var quote []string
+			for ; i < len(lines); i++ {
+				t := strings.TrimSpace(lines[i])
+				if !strings.HasPrefix(t, ">") {
+					break
+				}
+				t = strings.TrimPrefix(t, ">")
+				quote = append(quote, strings.TrimPrefix(t, " "))
+			}
+			b.WriteString("<blockquote>\n")
+			render(b, quote)
+			b.WriteString("</blockquote>\n")
+		case bulletRe.MatchString(line):
+			i = list(b, lines, i, bulletRe, "ul")
+		case orderedRe.MatchString(line):
+			i = list(b, lines, i, orderedRe, "ol")
+		default:
+			var para []string
+			for ; i < len(lines); i++ {
+				l := lines[i]
+				if strings.TrimSpace(l) == "" || (len(para) > 0 && interrupts(l)) {
+					break
+				}
+				// trailing spaces are kept for hard line breaks
+				para = append(para, strings.TrimLeft(l, " \t"))
+			}
+			fmt.Fprintf(b, "<p>%s</p>\n", inline(strings.TrimRight(strings.Join(para, "\n"), " \t")))
+		}
+	}
+}
+
+// interrupts returns true if a line starts a new block
+// that ends a paragraph.
+func interrupts(line string) bool {
+	t := strings.TrimSpace(line)
+	return headingRe.MatchString(t) || ruleRe.MatchString(line) ||
+		fenceRe.MatchString(line) || strings.HasPrefix(t, ">") ||
+		bulletRe.MatchString(line) || orderedRe.MatchString(line)
+}
+
+func writeCode(b *strings.Builder, code []string, lang string) {
+	if lang != "" {
+		fmt.Fprintf(b, `<pre><code class="language-%s">`, html.EscapeString(lang))
+	} else {
+		b.WriteString("<pre><code>")
+	}
+	for _, l := range code {
+		b.WriteString(html.EscapeString(l))
+		b.WriteByte('\n')
+	}
+	b.WriteString("</code></pre>\n")
+}
+
+// list renders the list starting at line i and returns
+// the index of the first line after the list.
+func list(b *strings.Builder, lines []string, i int, marker *regexp.Regexp, tag string) int {
+	var items [][]string
+	if tag == "ol" {
+		m := marker.FindStringSubmatch(lines[i])
+		if m[1] != "1" {
+			fmt.Fprintf(b, "<ol start=\"%s\">\n", strings.TrimLeft(m[1], "0"))
+		} else {
+			b.WriteString("<ol>\n")
+		}
+	} else {
+		b.WriteString("<ul>\n")
+	}
+	loose := false
+	for i < len(lines) {
+		l := lines[i]
+		if m := marker.FindStringSubmatch(l); m != nil {
+			items = append(items, []string{m[2]})
+			i++
+			continue
+		}
+		if strings.TrimSpace(l) == "" {
+			// a blank line only continues the list if
+			// the next line is indented or another item
+			if i+1 < len(lines) && (marker.MatchString(lines[i+1]) || indented(lines[i+1])) {
+				loose = true
+				items[len(items)-1] = append(items[len(items)-1], "")
+				i++
+				continue
+			}
+			break
+		}
+		if indented(l) || !interrupts(l) {
+			items[len(items)-1] = append(items[len(items)-1], strings.TrimSpace(l))
+			i++
+			continue
+		}
+		break
+	}
+	for _, item := range items {
+		var inner strings.Builder
+		render(&inner, item)
+		body := inner.String()
+		if !loose {
+			// tight lists don't wrap their text in paragraphs
+			body = strings.TrimSuffix(strings.TrimPrefix(body, "<p>"), "</p>\n")
+			body = strings.Replace(body, "</p>\n", "\n", 1)
+		}
+		fmt.Fprintf(b, "<li>%s</li>\n", strings.TrimSuffix(body, "\n"))
+	}
+	fmt.Fprintf(b, "</%s>\n", tag)
+	return i
+}
+
+func indented(line string) bool {
+	return strings.HasPrefix(line, "  ") || strings.HasPrefix(line, "\t")
+}
+
+var (
+	codeSpanRe = regexp.MustCompile("(`+)(.+?)(`+)")
+	imageRe    = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)(?:\s+&#34;([^)]*)&#34;)?\)`)
+	linkRe     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)(?:\s+&#34;([^)]*)&#34;)?\)`)
+	autolinkRe = regexp.MustCompile(`&lt;((?:https?|mailto):[^\s&]+)&gt;`)
+	strongRe   = regexp.MustCompile(`\*\*([^\s*](?:.*?[^\s*])?)\*\*|__([^\s_](?:.*?[^\s_])?)__`)
+	emRe       = regexp.MustCompile(`\*([^\s*](?:[^*]*?[^\s*])?)\*|\b_([^\s_](?:[^_]*?[^\s_])?)_\b`)
+	breakRe    = regexp.MustCompile(`(?: {2,}|\\)\n`)
+)
+
+// inline converts the inline syntax of a block of text.
+func inline(text string) string {
+	// code spans are taken out first so that nothing
+	// inside of them gets converted
+	var spans []string
+	text = codeSpanRe.ReplaceAllStringFunc(text, func(s string) string {
+		m := codeSpanRe.FindStringSubmatch(s)
+		if m[1] != m[3] {
+			return s
+		}
+		spans = append(spans, "<code>"+html.EscapeString(strings.TrimSpace(m[2]))+"</code>")
+		return fmt.Sprintf("\x00%d\x00", len(spans)-1)
+	})
+	text = html.EscapeString(text)
+	text = imageRe.ReplaceAllStringFunc(text, func(s string) string {
+		m := imageRe.FindStringSubmatch(s)
+		src, ok := safeURL(m[2])
+		if !ok {
+			return m[1]
+		}
+		return fmt.Sprintf(`<img src="%s" alt="%s"%s>`, src, m[1], title(m[3]))
+	})
+	text = linkRe.ReplaceAllStringFunc(text, func(s string) string {
+		m := linkRe.FindStringSubmatch(s)
+		href, ok := safeURL(m[2])
+		if !ok {
+			return m[1]
+		}
+		return fmt.Sprintf(`<a href="%s"%s>%s</a>`, href, title(m[3]), m[1])
+	})
+	text = autolinkRe.ReplaceAllString(text, `<a href="$1">$1</a>`)
+	text = strongRe.ReplaceAllString(text, "<strong>$1$2</strong>")
+	text = emRe.ReplaceAllString(text, "<em>$1$2</em>")
+	text = breakRe.ReplaceAllString(text, "<br>\n")
+	for i, span := range spans {
+		text = strings.Replace(text, fmt.Sprintf("\x00%d\x00", i), span, 1)
+	}
+	return text
+}
+
+// safeURL checks an escaped url from a link or image and returns
+// it escaped for an attribute. Only http, https, mailto and relative
+// urls are allowed so that links like "javascript:" are not live.
+func safeURL(escaped string) (string, bool) {
+	raw := html.UnescapeString(escaped)
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", false
+	}
+	switch u.Scheme {
+	case "", "http", "https", "mailto":
+		return html.EscapeString(raw), true
+	}
+	return "", false
+}
+
+func title(t string) string {
+	if t == "" {
+		return ""
+	}
+	return fmt.Sprintf(` title="%s"`, t)
+}
@@ -0,0 +1,37 @@
+package markdown
+
+import "testing"
+
+func TestToHTML(t *testing.T) {
+	tests := []struct {
+		md, exp string
+	}{
+		{"# Title", "<h1>Title</h1>\n"},
+		{"### sub ###\ntext", "<h3>sub</h3>\n<p>text</p>\n"},
+		{"#nope", "<p>#nope</p>\n"},
+		{"one\ntwo\n\nthree", "<p>one\ntwo</p>\n<p>three</p>\n"},
+		{"a  \nb", "<p>a<br>\nb</p>\n"},
+		{"**bold** and *em* and _em_ snake_case_name", "<p><strong>bold</strong> and <em>em</em> and <em>em</em> snake_case_name</p>\n"},
+		{"use `a < b` and `*x*`", "<p>use <code>a &lt; b</code> and <code>*x*</code></p>\n"},
+		{"<b>raw</b> & more", "<p>&lt;b&gt;raw&lt;/b&gt; &amp; more</p>\n"},
+		{"[edu](https://example.com?a=1&b=2 \"t\")", `<p><a href="https://example.com?a=1&amp;b=2" title="t">edu</a></p>` + "\n"},
+		{"![plot](plot.png)", `<p><img src="plot.png" alt="plot"></p>` + "\n"},
+		{"[x](javascript:alert(1))", "<p>x)</p>\n"},
+		{"[x](JavaScript:void) ![y](data:image/png)", "<p>x y</p>\n"},
+		{"[mail](mailto:a@b.c) [up](../notes.md)", `<p><a href="mailto:a@b.c">mail</a> <a href="../notes.md">up</a></p>` + "\n"},
+		{"<https://example.com>", `<p><a href="https://example.com">https://example.com</a></p>` + "\n"},
+		{"```go\nif a < b {\n}\n```", "<pre><code class=\"language-go\">if a &lt; b {\n}\n</code></pre>\n"},
+		{"    indented\n    code\n\nafter", "<pre><code>indented\ncode\n</code></pre>\n<p>after</p>\n"},
+		{"> quoted\n> *text*", "<blockquote>\n<p>quoted\n<em>text</em></p>\n</blockquote>\n"},
+		{"---", "<hr>\n"},
+		{"- a\n- b\n  more\n* c", "<ul>\n<li>a</li>\n<li>b\nmore</li>\n<li>c</li>\n</ul>\n"},
+		{"3. three\n4. four", "<ol start=\"3\">\n<li>three</li>\n<li>four</li>\n</ol>\n"},
+		{"1. a\n\n2. b\n\nend", "<ol>\n<li><p>a</p></li>\n<li><p>b</p></li>\n</ol>\n<p>end</p>\n"},
+		{"text\n- item", "<p>text</p>\n<ul>\n<li>item</li>\n</ul>\n"},
+	}
+	for _, tst := range tests {
+		if res := ToHTML(tst.md); res != tst.exp {
+			t.Errorf("wrong html for %q:\ngot  %q\nwant %q", tst.md, res, tst.exp)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package pdftext
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	opToken tokenKind = iota
+	stringToken
+	numberToken
+	otherToken
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+}
+
+// lexer splits a content stream into tokens.
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func isSpace(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '\f', 0:
+		return true
+	}
+	return false
+}
+
+func isDelim(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func (l *lexer) next() (token, bool) {
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case isSpace(c):
+			l.pos++
+		case c == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		case c == '(':
+			return token{kind: stringToken, value: l.literal()}, true
+		case c == '<':
+			if l.pos+1 < len(l.data) && l.data[l.pos+1] == '<' {
+				l.pos += 2
+				return token{kind: otherToken, value: "<<"}, true
+			}
+			return token{kind: stringToken, value: l.hexString()}, true
+		case c == '>':
+			l.pos++
+			if l.pos < len(l.data) && l.data[l.pos] == '>' {
+				l.pos++
+			}
+			return token{kind: otherToken, value: ">>"}, true
+		case c == '[' || c == ']' || c == '{' || c == '}':
+			l.pos++
+			return token{kind: otherToken, value: string(c)}, true
+		case c == ')':
+			l.pos++
+		case c == '/':
+			l.pos++
+			return token{kind: otherToken, value: "/" + l.word()}, true
+		default:
+			w := l.word()
+			if w == "" {
+				l.pos++
+				continue
+			}
+			if strings.IndexFunc(w, func(r rune) bool {
+				return (r < '0' || r > '9') && r != '.' && r != '-' && r != '+'
+			}) < 0 {
+				return token{kind: numberToken, value: w}, true
+			}
+			if w == "BI" {
+				l.skipImage()
+				continue
+			}
+			return token{kind: opToken, value: w}, true
+		}
+	}
+	return token{}, false
+}
+
+func (l *lexer) word() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isSpace(l.data[l.pos]) && !isDelim(l.data[l.pos]) {
+		l.pos++
+	}
+	return string(l.data[start:l.pos])
+}
+
+// literal reads a string in parentheses. Parentheses
+// can be nested and there are backslash escapes.
+func (l *lexer) literal() string {
+	var (
+		b     strings.Builder
+		depth = 0
+	)
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+			if depth > 1 {
+				b.WriteByte(c)
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return b.String()
+			}
+			b.WriteByte(c)
+		case '\\':
+			if l.pos >= len(l.data) {
+				return b.String()
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case '\r', '\n':
+				// line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					n := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						n = n*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					b.WriteByte(byte(n))
+				} else {
+					b.WriteByte(e)
+				}
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func (l *lexer) hexString() string {
+	l.pos++ // '<'
+	start := l.pos
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		l.pos++
+	}
+	digits := make([]byte, 0, l.pos-start+1)
+	for _, c := range l.data[start:l.pos] {
+		if !isSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	l.pos++ // '>'
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	b, err := hex.DecodeString(string(digits))
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// skipImage skips the data of an inline image.
+func (l *lexer) skipImage() {
+	for l.pos+2 < len(l.data) {
+		if l.data[l.pos] == 'E' && l.data[l.pos+1] == 'I' &&
+			isSpace(l.data[l.pos-1]) && isSpace(l.data[l.pos+2]) {
+			l.pos += 2
+			return
+		}
+		l.pos++
+	}
+	l.pos = len(l.data)
+}
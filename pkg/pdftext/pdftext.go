@@ -0,0 +1,234 @@
+// Package pdftext extracts plain text from pdf files.
+//
+// It only understands the parts of a pdf that are needed to find the
+// text drawn on each page: the content streams (raw or flate encoded)
+// and the text showing operators inside of them. Fonts with custom
+// encodings are not decoded so some pdfs will give partial text.
+package pdftext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// File extracts the text from a pdf file.
+func File(filename string) (string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	return Extract(data)
+}
+
+// Extract returns the text from the raw bytes of a pdf.
+func Extract(data []byte) (string, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF")) {
+		return "", os.ErrInvalid
+	}
+	var b strings.Builder
+	for _, s := range streams(data) {
+		if !bytes.Contains(s, []byte("BT")) {
+			continue
+		}
+		text(&b, s)
+	}
+	return b.String(), nil
+}
+
+var (
+	streamKey = []byte("stream")
+	endKey    = []byte("endstream")
+	objKey    = []byte("obj")
+)
+
+// streams finds and decodes every stream that could be a page
+// content stream.
+func streams(data []byte) [][]byte {
+	var (
+		result [][]byte
+		pos    int
+	)
+	for {
+		i := bytes.Index(data[pos:], streamKey)
+		if i < 0 {
+			break
+		}
+		start := pos + i
+		pos = start + len(streamKey)
+		if start >= 3 && bytes.Equal(data[start-3:start], []byte("end")) {
+			continue
+		}
+		// the stream keyword is followed by an end of line
+		body := pos
+		if body < len(data) && data[body] == '\r' {
+			body++
+		}
+		if body < len(data) && data[body] == '\n' {
+			body++
+		}
+		end := bytes.Index(data[body:], endKey)
+		if end < 0 {
+			break
+		}
+		raw := bytes.TrimRight(data[body:body+end], "\r\n")
+		pos = body + end + len(endKey)
+
+		dictStart := bytes.LastIndex(data[:start], objKey)
+		if dictStart < 0 {
+			dictStart = 0
+		}
+		dict := data[dictStart:start]
+		if skipStream(dict) {
+			continue
+		}
+		if bytes.Contains(dict, []byte("/FlateDecode")) || bytes.Contains(dict, []byte("/Fl ")) {
+			raw = inflate(raw)
+		} else if bytes.Contains(dict, []byte("/Filter")) {
+			// other filters are used for images and fonts
+			continue
+		}
+		if len(raw) > 0 {
+			result = append(result, raw)
+		}
+	}
+	return result
+}
+
+func skipStream(dict []byte) bool {
+	for _, key := range []string{
+		"/Image", "/XRef", "/ObjStm", "/Metadata",
+		"/Length1", "/Length2", "/Length3", "/FontFile",
+	} {
+		if bytes.Contains(dict, []byte(key)) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxStreamSize is the most a single stream may inflate to. Streams
+// that are larger than this are skipped.
+const maxStreamSize = 16 << 20
+
+// inflate decompresses as much of a stream as it can. It returns
+// nil if the stream inflates to more than maxStreamSize bytes.
+func inflate(raw []byte) []byte {
+	r, err := zlib.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil
+	}
+	defer r.Close()
+	var buf bytes.Buffer
+	io.Copy(&buf, io.LimitReader(r, maxStreamSize+1))
+	if buf.Len() > maxStreamSize {
+		return nil
+	}
+	return buf.Bytes()
+}
+
+// text runs the text operators in a content stream
+// and writes the text to b.
+func text(b *strings.Builder, content []byte) {
+	var (
+		lex      = lexer{data: content}
+		operands []token
+	)
+	space := func() {
+		s := b.String()
+		if len(s) > 0 && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+			b.WriteByte(' ')
+		}
+	}
+	newline := func() {
+		s := b.String()
+		if len(s) > 0 && !strings.HasSuffix(s, "\n") {
+			b.WriteByte('\n')
+		}
+	}
+	for {
+		tok, ok := lex.next()
+		if !ok {
+			break
+		}
+		if tok.kind != opToken {
+			operands = append(operands, tok)
+			continue
+		}
+		switch tok.value {
+		case "Tj", "'", "\"":
+			if tok.value != "Tj" {
+				newline()
+			}
+			if n := len(operands); n > 0 && operands[n-1].kind == stringToken {
+				b.WriteString(decode(operands[n-1].value))
+			}
+		case "TJ":
+			for _, op := range operands {
+				switch op.kind {
+				case stringToken:
+					b.WriteString(decode(op.value))
+				case numberToken:
+					// large negative offsets are spaces between words
+					if strings.HasPrefix(op.value, "-") && len(strings.TrimLeft(op.value, "-")) >= 3 {
+						space()
+					}
+				}
+			}
+		case "Td", "TD":
+			if n := len(operands); n >= 2 && operands[n-1].value != "0" {
+				newline()
+			} else {
+				space()
+			}
+		case "T*", "ET":
+			newline()
+		case "Tm":
+			space()
+		}
+		operands = operands[:0]
+	}
+	newline()
+}
+
+// decode turns the bytes of a pdf string into text.
+func decode(s string) string {
+	if strings.HasPrefix(s, "\xfe\xff") {
+		s = s[2:]
+		u := make([]uint16, 0, len(s)/2)
+		for i := 0; i+1 < len(s); i += 2 {
+			u = append(u, uint16(s[i])<<8|uint16(s[i+1]))
+		}
+		return string(utf16.Decode(u))
+	}
+	// two byte strings where the first byte is always zero
+	if len(s) > 1 && len(s)%2 == 0 {
+		wide := true
+		for i := 0; i < len(s); i += 2 {
+			if s[i] != 0 {
+				wide = false
+				break
+			}
+		}
+		if wide {
+			var b strings.Builder
+			for i := 1; i < len(s); i += 2 {
+				b.WriteByte(s[i])
+			}
+			s = b.String()
+		}
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		r := rune(s[i]) // pdf doc encoding is mostly latin-1
+		if unicode.IsPrint(r) || r == ' ' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
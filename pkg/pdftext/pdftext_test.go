@@ -0,0 +1,75 @@
+package pdftext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func makePDF(content string, compress bool) []byte {
+	var (
+		buf    bytes.Buffer
+		stream = []byte(content)
+		filter = ""
+	)
+	if compress {
+		var z bytes.Buffer
+		w := zlib.NewWriter(&z)
+		w.Write(stream)
+		w.Close()
+		stream = z.Bytes()
+		filter = " /Filter /FlateDecode"
+	}
+	buf.WriteString("%PDF-1.4\n")
+	buf.WriteString("1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	buf.WriteString("2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count 1 >>\nendobj\n")
+	buf.WriteString("3 0 obj\n<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>\nendobj\n")
+	fmt.Fprintf(&buf, "4 0 obj\n<< /Length %d%s >>\nstream\n", len(stream), filter)
+	buf.Write(stream)
+	buf.WriteString("\nendstream\nendobj\ntrailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return buf.Bytes()
+}
+
+func TestExtract(t *testing.T) {
+	content := `BT /F1 12 Tf 72 712 Td (AVL Tree Rotations) Tj 0 -14 Td
+[(A left ) -20 (rota) 5 (tion) -300 (fixes)] TJ T* <0062006100630068> Tj
+(nested \(parens\) and \101scape) ' ET`
+	for _, compress := range []bool{false, true} {
+		text, err := Extract(makePDF(content, compress))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"AVL Tree Rotations\n",
+			"A left rotation fixes",
+			"bach",
+			"nested (parens) and Ascape",
+		} {
+			if !strings.Contains(text, want) {
+				t.Errorf("compress=%v: %q not found in %q", compress, want, text)
+			}
+		}
+	}
+	if _, err := Extract([]byte("not a pdf")); err == nil {
+		t.Error("expected an error for a file that is not a pdf")
+	}
+}
+
+func TestInflateLimit(t *testing.T) {
+	var z bytes.Buffer
+	w := zlib.NewWriter(&z)
+	w.Write(make([]byte, maxStreamSize+1))
+	w.Close()
+	if b := inflate(z.Bytes()); b != nil {
+		t.Errorf("expected an oversized stream to be skipped, got %d bytes", len(b))
+	}
+	z.Reset()
+	w = zlib.NewWriter(&z)
+	w.Write([]byte("BT (hi) Tj ET"))
+	w.Close()
+	if b := inflate(z.Bytes()); string(b) != "BT (hi) Tj ET" {
+		t.Errorf("got %q", b)
+	}
+}
@@ -0,0 +1,235 @@
+package term
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Progress renders a group of progress bars at the bottom of
+// the terminal. Anything written to a Progress will be printed
+// above the bars.
+type Progress struct {
+	out      io.Writer
+	mu       sync.Mutex
+	bars     []*Bar
+	total    *Bar
+	lines    int
+	interval time.Duration
+	done     chan struct{}
+}
+
+// NewProgress creates a new Progress that writes to out.
+func NewProgress(out io.Writer) *Progress {
+	p := &Progress{
+		out:      out,
+		interval: 150 * time.Millisecond,
+	}
+	p.total = &Bar{Name: "total", start: time.Now()}
+	return p
+}
+
+// Total returns the bar that tracks the sum of all the other bars.
+func (p *Progress) Total() *Bar {
+	return p.total
+}
+
+// NewBar adds a new bar. Size is the total number of bytes expected.
+func (p *Progress) NewBar(name string, size int64) *Bar {
+	b := &Bar{
+		Name:   name,
+		size:   size,
+		start:  time.Now(),
+		parent: p.total,
+	}
+	atomic.AddInt64(&p.total.size, size)
+	p.mu.Lock()
+	p.bars = append(p.bars, b)
+	p.mu.Unlock()
+	return b
+}
+
+// Remove will stop rendering a bar.
+func (p *Progress) Remove(b *Bar) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, bar := range p.bars {
+		if bar == b {
+			p.bars = append(p.bars[:i], p.bars[i+1:]...)
+			return
+		}
+	}
+}
+
+// Write prints to the output above the progress bars. If the bars
+// are not being rendered then b is written straight to the output.
+func (p *Progress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done == nil {
+		return p.out.Write(b)
+	}
+	p.clear()
+	n, err := p.out.Write(b)
+	p.render()
+	return n, err
+}
+
+// Start will start rendering the bars.
+func (p *Progress) Start() {
+	done := make(chan struct{})
+	p.mu.Lock()
+	p.done = done
+	p.mu.Unlock()
+	CursorOff()
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				p.mu.Lock()
+				// don't draw over the terminal if Stop was
+				// called while waiting for the lock
+				if p.done == done {
+					p.clear()
+					p.render()
+				}
+				p.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// Stop will stop rendering and remove the bars from the terminal.
+// It is safe to call more than once.
+func (p *Progress) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done == nil {
+		return
+	}
+	close(p.done)
+	p.done = nil
+	p.clear()
+	CursorOn()
+}
+
+func (p *Progress) clear() {
+	for i := 0; i < p.lines; i++ {
+		fmt.Fprint(p.out, control("1A"), control("2K"))
+	}
+	p.lines = 0
+}
+
+func (p *Progress) render() {
+	var buf bytes.Buffer
+	for _, b := range p.bars {
+		buf.WriteString(b.String())
+		buf.WriteByte('\n')
+	}
+	if len(p.bars) > 0 || p.total.Current() > 0 {
+		buf.WriteString(p.total.String())
+		buf.WriteByte('\n')
+	}
+	p.lines = bytes.Count(buf.Bytes(), []byte{'\n'})
+	p.out.Write(buf.Bytes())
+}
+
+// Bar is a progress bar that counts bytes. Writing to a Bar
+// will only count the number of bytes written.
+type Bar struct {
+	Name    string
+	size    int64
+	current int64
+	start   time.Time
+	parent  *Bar
+}
+
+const (
+	barWidth  = 30
+	nameWidth = 30
+)
+
+// Add will add n bytes to the bar's progress.
+func (b *Bar) Add(n int64) {
+	atomic.AddInt64(&b.current, n)
+	if b.parent != nil {
+		b.parent.Add(n)
+	}
+}
+
+// Write counts the bytes in p.
+func (b *Bar) Write(p []byte) (int, error) {
+	b.Add(int64(len(p)))
+	return len(p), nil
+}
+
+// Current returns the number of bytes counted so far.
+func (b *Bar) Current() int64 {
+	return atomic.LoadInt64(&b.current)
+}
+
+// Rate returns the number of bytes per second.
+func (b *Bar) Rate() float64 {
+	elapsed := time.Since(b.start).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(b.Current()) / elapsed
+}
+
+// ETA is the estimated time left until the bar is full.
+func (b *Bar) ETA() time.Duration {
+	rate := b.Rate()
+	left := atomic.LoadInt64(&b.size) - b.Current()
+	if rate <= 0 || left <= 0 {
+		return 0
+	}
+	return time.Duration(float64(left)/rate) * time.Second
+}
+
+func (b *Bar) String() string {
+	var (
+		size    = atomic.LoadInt64(&b.size)
+		current = b.Current()
+		filled  = 0
+	)
+	if size > 0 {
+		filled = int(current * barWidth / size)
+	}
+	if filled > barWidth {
+		filled = barWidth
+	}
+	name := b.Name
+	if len(name) > nameWidth {
+		name = "..." + name[len(name)-nameWidth+3:]
+	}
+	return fmt.Sprintf("%-*s [%s%s] %9s/%-9s %9s/s ETA %s",
+		nameWidth, name,
+		strings.Repeat("=", filled), strings.Repeat(" ", barWidth-filled),
+		Bytes(current), Bytes(size),
+		Bytes(int64(b.Rate())),
+		b.ETA().Round(time.Second),
+	)
+}
+
+// Bytes formats a number of bytes so that it is human readable.
+func Bytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,92 @@
+package term
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		n   int64
+		exp string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{1024, "1.0KiB"},
+		{1536, "1.5KiB"},
+		{5 * 1024 * 1024, "5.0MiB"},
+	}
+	for _, tst := range tests {
+		if res := Bytes(tst.n); res != tst.exp {
+			t.Errorf("wrong output for %d: got %s; want %s", tst.n, res, tst.exp)
+		}
+	}
+}
+
+func TestBar(t *testing.T) {
+	p := NewProgress(nil)
+	a := p.NewBar("a", 10)
+	b := p.NewBar("b", 20)
+	a.Write(make([]byte, 4))
+	b.Add(6)
+	if a.Current() != 4 {
+		t.Errorf("expected 4 bytes, got %d", a.Current())
+	}
+	if p.Total().Current() != 10 {
+		t.Errorf("total should count all bars: got %d", p.Total().Current())
+	}
+	p.Remove(a)
+	if len(p.bars) != 1 || p.bars[0] != b {
+		t.Error("bar was not removed")
+	}
+}
+
+func TestProgressStop(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		wg  sync.WaitGroup
+		p   = NewProgress(&buf)
+	)
+	p.interval = time.Millisecond
+	p.NewBar("a", 10).Add(5)
+	p.Start()
+	time.Sleep(5 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Stop()
+		}()
+	}
+	wg.Wait()
+	p.Stop()
+	if p.lines != 0 {
+		t.Error("bars should be cleared after stopping")
+	}
+}
+
+func TestProgressWrite(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		p   = NewProgress(&buf)
+	)
+	p.interval = time.Hour
+	p.NewBar("a", 10).Add(5)
+	p.Write([]byte("before\n"))
+	if buf.String() != "before\n" {
+		t.Errorf("expected a plain write before Start, got %q", buf.String())
+	}
+	p.Start()
+	p.Write([]byte("during\n"))
+	if p.lines == 0 {
+		t.Error("bars should be rendered after a write while running")
+	}
+	p.Stop()
+	buf.Reset()
+	p.Write([]byte("after\n"))
+	if buf.String() != "after\n" || p.lines != 0 {
+		t.Errorf("expected a plain write after Stop, got %q", buf.String())
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 )
 
 var (
@@ -84,3 +85,44 @@ func control(code string) string {
 	}
 	return fmt.Sprintf("%s[%s", escape, code)
 }
+
+// IsTerminal returns true if the file is a terminal.
+func IsTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// Duration formats a duration using its two largest
+// units (ex. "2d 3h" or "5m") so that it is human readable.
+func Duration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d/time.Second))
+	}
+	units := []struct {
+		d    time.Duration
+		name string
+	}{
+		{24 * time.Hour, "d"},
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+	}
+	for i, u := range units {
+		if d < u.d {
+			continue
+		}
+		s := fmt.Sprintf("%d%s", d/u.d, u.name)
+		if i+1 < len(units) {
+			if rest := (d % u.d) / units[i+1].d; rest > 0 {
+				s += fmt.Sprintf(" %d%s", rest, units[i+1].name)
+			}
+		}
+		return s
+	}
+	return d.String()
+}
@@ -0,0 +1,38 @@
+package term
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestIsTerminal(t *testing.T) {
+	f, err := ioutil.TempFile("", "edu-term")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if IsTerminal(f) {
+		t.Error("a regular file is not a terminal")
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		d   time.Duration
+		exp string
+	}{
+		{30 * time.Second, "30s"},
+		{5 * time.Minute, "5m"},
+		{-90 * time.Minute, "1h 30m"},
+		{2 * time.Hour, "2h"},
+		{51 * time.Hour, "2d 3h"},
+	}
+	for _, tst := range tests {
+		if res := Duration(tst.d); res != tst.exp {
+			t.Errorf("wrong output for %v: got %s; want %s", tst.d, res, tst.exp)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+// Package throttle implements an http.RoundTripper that respects
+// the canvas api rate limits.
+//
+// Canvas gives every token a bucket of request credits and reports
+// how many are left in the X-Rate-Limit-Remaining header. Once the
+// bucket is empty, canvas will respond with 403 "Rate Limit Exceeded".
+// See https://canvas.instructure.com/doc/api/file.throttling.html
+package throttle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Defaults used by New.
+const (
+	DefaultRetries   = 5
+	DefaultBaseDelay = 500 * time.Millisecond
+	DefaultMaxDelay  = 30 * time.Second
+
+	// when the remaining quota falls below lowWater,
+	// requests start getting slowed down
+	lowWater = 300.0
+	// maxPause is the longest pause between requests
+	// when the quota is almost used up
+	maxPause = 2 * time.Second
+)
+
+// ErrRateLimited is returned when canvas is still
+// throttling requests after all the retries.
+var ErrRateLimited = errors.New("canvas rate limit exceeded")
+
+// RetryError is returned after a request has
+// been retried too many times.
+type RetryError struct {
+	Attempts int
+	Status   int
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	if e.Status != 0 {
+		return fmt.Sprintf("%v (status %d, gave up after %d attempts)", e.Err, e.Status, e.Attempts)
+	}
+	return fmt.Sprintf("%v (gave up after %d attempts)", e.Err, e.Attempts)
+}
+
+// Unwrap returns the underlying error.
+func (e *RetryError) Unwrap() error { return e.Err }
+
+// Transport is a rate limit aware http.RoundTripper.
+type Transport struct {
+	// Base is the underlying RoundTripper.
+	Base http.RoundTripper
+	// Host limits throttling to one host, all other
+	// requests are sent unchanged. Empty means all hosts.
+	Host string
+	// Retries is the number of times a request is retried.
+	Retries int
+	// BaseDelay is the starting backoff delay which
+	// doubles for every retry up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+
+	mu        sync.Mutex
+	remaining float64
+	known     bool
+}
+
+// New creates a new Transport that wraps base.
+func New(base http.RoundTripper, host string) *Transport {
+	return &Transport{
+		Base:      base,
+		Host:      host,
+		Retries:   DefaultRetries,
+		BaseDelay: DefaultBaseDelay,
+		MaxDelay:  DefaultMaxDelay,
+	}
+}
+
+// Remaining returns the last rate limit quota reported by canvas. The
+// second return value is false if canvas has not reported one yet.
+func (t *Transport) Remaining() (float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.remaining, t.known
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Host != "" && req.URL.Host != t.Host {
+		return t.base().RoundTrip(req)
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// can't send the body twice so don't retry
+		time.Sleep(t.pause())
+		resp, err := t.base().RoundTrip(req)
+		if err == nil {
+			t.update(resp)
+		}
+		return resp, err
+	}
+	var (
+		resp *http.Response
+		err  error
+	)
+	for attempt := 0; ; attempt++ {
+		time.Sleep(t.pause())
+		if attempt > 0 {
+			if req, err = rewind(req); err != nil {
+				return nil, err
+			}
+		}
+		resp, err = t.base().RoundTrip(req)
+		if err == nil {
+			t.update(resp)
+		} else if req.Context().Err() != nil {
+			return nil, err
+		}
+		retry, cause := t.shouldRetry(req, resp, err)
+		if !retry {
+			return resp, err
+		}
+		if attempt >= t.Retries {
+			status := 0
+			if resp != nil {
+				status = resp.StatusCode
+				resp.Body.Close()
+			}
+			return nil, &RetryError{Attempts: attempt + 1, Status: status, Err: cause}
+		}
+		wait := t.backoff(attempt, resp)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+// update records the rate limit quota from a response.
+func (t *Transport) update(resp *http.Response) {
+	h := resp.Header.Get("X-Rate-Limit-Remaining")
+	if h == "" {
+		return
+	}
+	remaining, err := strconv.ParseFloat(h, 64)
+	if err != nil {
+		return
+	}
+	t.mu.Lock()
+	t.remaining = remaining
+	t.known = true
+	t.mu.Unlock()
+}
+
+// pause returns the time to wait before sending a request. It
+// grows as the remaining quota gets closer to zero.
+func (t *Transport) pause() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.known || t.remaining >= lowWater {
+		return 0
+	}
+	used := (lowWater - t.remaining) / lowWater
+	if used > 1 {
+		used = 1
+	}
+	return time.Duration(used * float64(maxPause))
+}
+
+// shouldRetry decides if a request should be sent again. Requests
+// that are not idempotent are only retried when canvas throttled
+// them because otherwise they may have already been handled.
+func (t *Transport) shouldRetry(req *http.Request, resp *http.Response, err error) (bool, error) {
+	if err != nil {
+		// network errors are usually worth another try
+		return idempotent(req), err
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true, ErrRateLimited
+	case http.StatusForbidden:
+		if isThrottled(resp) {
+			return true, ErrRateLimited
+		}
+	case http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return idempotent(req), errors.New(http.StatusText(resp.StatusCode))
+	}
+	return false, nil
+}
+
+// idempotent returns true if sending a request more
+// than once has the same effect as sending it once.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions,
+		http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// backoff returns the jittered exponential backoff delay
+// for an attempt, or the Retry-After header if there is one.
+// It is never longer than MaxDelay.
+func (t *Transport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			return t.clamp(time.Duration(secs) * time.Second)
+		}
+	}
+	d := t.BaseDelay << uint(attempt)
+	if d > t.MaxDelay || d <= 0 {
+		d = t.MaxDelay
+	}
+	// add jitter so that concurrent requests don't all retry at once
+	return t.clamp(d/2 + time.Duration(rand.Int63n(int64(d)+1)))
+}
+
+func (t *Transport) clamp(d time.Duration) time.Duration {
+	switch {
+	case d < 0:
+		return 0
+	case d > t.MaxDelay:
+		return t.MaxDelay
+	}
+	return d
+}
+
+// isThrottled checks the body of a 403 for the rate limit message.
+// The body is put back so it can still be read by the caller.
+func isThrottled(resp *http.Response) bool {
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	return bytes.Contains(body, []byte("Rate Limit Exceeded"))
+}
+
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	r := req.Clone(req.Context())
+	r.Body = body
+	return r, nil
+}
@@ -0,0 +1,159 @@
+package throttle
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testClient(retries int) (*http.Client, *Transport) {
+	t := New(http.DefaultTransport, "")
+	t.Retries = retries
+	t.BaseDelay = time.Millisecond
+	t.MaxDelay = 5 * time.Millisecond
+	return &http.Client{Transport: t}, t
+}
+
+func TestRetry(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("X-Rate-Limit-Remaining", "650.5")
+		if calls < 3 {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("403 Forbidden (Rate Limit Exceeded)"))
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	client, tr := testClient(5)
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	if string(b) != "ok" {
+		t.Errorf("wrong body: %q", b)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+	if rem, ok := tr.Remaining(); !ok || rem != 650.5 {
+		t.Errorf("wrong remaining quota: %v", rem)
+	}
+}
+
+func TestGiveUp(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client, _ := testClient(2)
+	_, err := client.Get(srv.URL)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("expected a RetryError, got %T", err)
+	}
+	if retryErr.Attempts != 3 || calls != 3 {
+		t.Errorf("expected 3 attempts, got %d (%d calls)", retryErr.Attempts, calls)
+	}
+}
+
+func TestNoRetry(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errors":[{"message":"user not authorized"}]}`))
+	}))
+	defer srv.Close()
+
+	client, _ := testClient(5)
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusForbidden || len(b) == 0 {
+		t.Error("non-throttling 403 should be passed through with its body")
+	}
+	if calls != 1 {
+		t.Errorf("should not retry a normal 403, got %d calls", calls)
+	}
+}
+
+func TestNoRetryPost(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	client, _ := testClient(5)
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("submission"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || calls != 1 {
+		t.Errorf("a POST should not be retried after a 503, got %d calls", calls)
+	}
+
+	calls = 1
+	_, err = client.Post(srv.URL, "text/plain", strings.NewReader("submission"))
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("a throttled POST should be retried, got %v", err)
+	}
+	if calls != 7 {
+		t.Errorf("expected 6 attempts, got %d", calls-1)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tr := New(nil, "")
+	tr.BaseDelay = time.Second
+	tr.MaxDelay = 4 * time.Second
+	resp := &http.Response{Header: http.Header{"Retry-After": {"3600"}}}
+	if d := tr.backoff(0, resp); d != tr.MaxDelay {
+		t.Errorf("Retry-After should be capped at MaxDelay, got %v", d)
+	}
+	for attempt := 0; attempt < 10; attempt++ {
+		if d := tr.backoff(attempt, nil); d < 0 || d > tr.MaxDelay {
+			t.Errorf("attempt %d: backoff %v is not within 0 and MaxDelay", attempt, d)
+		}
+	}
+}
+
+func TestPause(t *testing.T) {
+	tr := New(nil, "")
+	if tr.pause() != 0 {
+		t.Error("should not pause before any quota is known")
+	}
+	tr.remaining, tr.known = 700, true
+	if tr.pause() != 0 {
+		t.Error("should not pause with a full quota")
+	}
+	tr.remaining = 0
+	if tr.pause() != maxPause {
+		t.Errorf("expected max pause, got %v", tr.pause())
+	}
+}